@@ -136,7 +136,7 @@ func drawSphereWithLight() {
 
 	lightPosition := core.NewPoint(-5, 5, 55)
 	lightColor := color.NewColor(1, 1, 1)
-	light := lighting.NewLight(*lightColor, *lightPosition)
+	light := lighting.NewPointLight(*lightColor, *lightPosition)
 
 	for h := 0; h < canvas.Height; h++ {
 		for w := 0; w < canvas.Width; w++ {
@@ -146,7 +146,7 @@ func drawSphereWithLight() {
 
 			// TODO: A better interface possible ?
 			// Intersect the ray with sphere
-			intersections := ray.IntersectSphere(*sphere)
+			intersections := ray.Intersect(sphere)
 			hit := ray.Hit(intersections)
 
 			if hit != nil {
@@ -154,7 +154,7 @@ func drawSphereWithLight() {
 				point := ray.Position(hit.T)
 				normal := lighting.NormalAt(hit.Object, *point)
 				eye := ray.Direction.Reverse()
-				color := lighting.Lighting(hit.Object.Material, light, *point, *eye, normal, false)
+				color := lighting.Lighting(*hit.Object.GetMaterial(), hit.Object, light, *point, *eye, normal, 1)
 
 				canvas.WritePixel(int(pixel.X), int(pixel.Y), color)
 			}
@@ -170,17 +170,15 @@ func createSixSphereScene() {
 	// Create a new world
 	world := &scene.World{}
 
-	// 1. The floor is an extremely flattened sphere with a matte texture
-	floor := shape.UnitSphere()
-	floor.Transform = *core.ScaleM(10, 0.01, 10)
+	// 1. The floor is a plane with a matte texture
+	floor := shape.NewPlane()
 	floor.Material = material.DefaultMaterial()
 	floor.Material.Color = *color.NewColor(1, 0.9, 0.9)
 	floor.Material.Specular = 0
 
 	// 2. The wall on the left
-	leftWall := shape.UnitSphere()
+	leftWall := shape.NewPlane()
 	leftWall.Transform = *core.ChainTransforms([]*core.Matrix{
-		core.ScaleM(10, 0.01, 10),
 		core.RotateXM(math.Pi / 2),
 		core.RotateYM(-math.Pi / 4),
 		core.TranslationM(0, 0, 5),
@@ -188,9 +186,8 @@ func createSixSphereScene() {
 	leftWall.Material = floor.Material
 
 	// 3. The wall on the right
-	rightWall := shape.UnitSphere()
+	rightWall := shape.NewPlane()
 	rightWall.Transform = *core.ChainTransforms([]*core.Matrix{
-		core.ScaleM(10, 0.01, 10),
 		core.RotateXM(math.Pi / 2),
 		core.RotateYM(math.Pi / 4),
 		core.TranslationM(0, 0, 5),
@@ -228,13 +225,13 @@ func createSixSphereScene() {
 	left.Material.Specular = 0.3
 
 	// The light source is white, shining from above and to the left
-	light := lighting.NewLight(
+	light := lighting.NewPointLight(
 		*color.NewColor(1, 1, 1),
 		*core.NewPoint(-10, 10, -10),
 	)
-	// Add all spheres to the world
-	world.Spheres = append(world.Spheres, *floor, *leftWall, *rightWall, *middle, *right, *left)
-	world.Light = light
+	// Add all objects to the world
+	world.Objects = append(world.Objects, floor, leftWall, rightWall, middle, right, left)
+	world.Lights = []lighting.Light{light}
 
 	// Configure the camera
 	camera := scene.NewCamera(800, 600, math.Pi/3)
@@ -245,7 +242,7 @@ func createSixSphereScene() {
 	)
 
 	// Render the result to a canvas
-	canvas := scene.Render(*camera, *world)
+	canvas := scene.RenderParallel(*camera, *world, scene.RenderOptions{})
 
 	// Save the image
 	err := canvas.WriteToPPM("six_spheres_scene.ppm")
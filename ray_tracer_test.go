@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	imageColor "image/color"
+	"image/png"
 	"math"
+	"os"
+	"strings"
 	"testing"
 
 	color "github.com/Naveenaidu/gray/src/core/color"
 	core "github.com/Naveenaidu/gray/src/core/math"
 	"github.com/Naveenaidu/gray/src/lighting"
 	"github.com/Naveenaidu/gray/src/material"
+	"github.com/Naveenaidu/gray/src/noise"
+	"github.com/Naveenaidu/gray/src/pattern"
 	"github.com/Naveenaidu/gray/src/rayt"
 	"github.com/Naveenaidu/gray/src/rendering"
 	"github.com/Naveenaidu/gray/src/scene"
@@ -1093,7 +1101,7 @@ func TestRayIntersectsSphereAtTwoPoints(t *testing.T) {
 	s := shape.UnitSphere()
 
 	// When xs ← intersect(s, r)
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 
 	// Then xs.count = 2
 	if len(xs) != 2 {
@@ -1123,7 +1131,7 @@ func TestRayIntersectsSphereAtTangent(t *testing.T) {
 	s := shape.UnitSphere()
 
 	// When xs ← intersect(s, r)
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 
 	// Then xs.count = 0
 	if len(xs) != 2 {
@@ -1153,7 +1161,7 @@ func TestRayMissesSphere(t *testing.T) {
 	s := shape.UnitSphere()
 
 	// When xs ← intersect(s, r)
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 
 	// Then xs.count = 0
 	if len(xs) != 0 {
@@ -1173,7 +1181,7 @@ func TestRayOriginatesInsideSphere(t *testing.T) {
 	s := shape.UnitSphere()
 
 	// When xs ← intersect(s, r)
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 
 	// Then xs.count = 2
 	if len(xs) != 2 {
@@ -1203,7 +1211,7 @@ func TestSphereIsBehindRay(t *testing.T) {
 	s := shape.UnitSphere()
 
 	// When xs ← intersect(s, r)
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 
 	// Then xs.count = 2
 	if len(xs) != 2 {
@@ -1223,8 +1231,8 @@ func TestSphereIsBehindRay(t *testing.T) {
 
 func TestHit_AllPositiveT(t *testing.T) {
 	s := shape.UnitSphere()
-	i1 := rayt.NewIntersection(1, *s)
-	i2 := rayt.NewIntersection(2, *s)
+	i1 := rayt.NewIntersection(1, s)
+	i2 := rayt.NewIntersection(2, s)
 	xs := []rayt.Intersection{i2, i1}
 
 	hit := rayt.Ray{}.Hit(xs)
@@ -1237,8 +1245,8 @@ func TestHit_AllPositiveT(t *testing.T) {
 
 func TestHit_SomeNegativeT(t *testing.T) {
 	s := shape.UnitSphere()
-	i1 := rayt.NewIntersection(-1, *s)
-	i2 := rayt.NewIntersection(1, *s)
+	i1 := rayt.NewIntersection(-1, s)
+	i2 := rayt.NewIntersection(1, s)
 	xs := []rayt.Intersection{i2, i1}
 
 	hit := rayt.Ray{}.Hit(xs)
@@ -1251,8 +1259,8 @@ func TestHit_SomeNegativeT(t *testing.T) {
 
 func TestHit_AllNegativeT(t *testing.T) {
 	s := shape.UnitSphere()
-	i1 := rayt.NewIntersection(-2, *s)
-	i2 := rayt.NewIntersection(-1, *s)
+	i1 := rayt.NewIntersection(-2, s)
+	i2 := rayt.NewIntersection(-1, s)
 	xs := []rayt.Intersection{i2, i1}
 
 	hit := rayt.Ray{}.Hit(xs)
@@ -1264,10 +1272,10 @@ func TestHit_AllNegativeT(t *testing.T) {
 
 func TestHit_LowestNonnegativeIntersection(t *testing.T) {
 	s := shape.UnitSphere()
-	i1 := rayt.NewIntersection(5, *s)
-	i2 := rayt.NewIntersection(7, *s)
-	i3 := rayt.NewIntersection(-3, *s)
-	i4 := rayt.NewIntersection(2, *s)
+	i1 := rayt.NewIntersection(5, s)
+	i2 := rayt.NewIntersection(7, s)
+	i3 := rayt.NewIntersection(-3, s)
+	i4 := rayt.NewIntersection(2, s)
 	xs := []rayt.Intersection{i1, i2, i3, i4}
 
 	hit := rayt.Ray{}.Hit(xs)
@@ -1360,7 +1368,7 @@ func TestIntersectingScaledSphereWithRay(t *testing.T) {
 	// When set_transform(s, scaling(2, 2, 2))
 	s.Transform = *core.ScaleM(2, 2, 2)
 
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 	// Then xs.count = 2
 	if len(xs) != 2 {
 		t.Errorf("Expected xs.count = 2, but got %d", len(xs))
@@ -1387,7 +1395,7 @@ func TestIntersectingTranslatedSphereWithRay(t *testing.T) {
 	// When set_transform(s, translation(5, 0, 0))
 	s.Transform = *core.TranslationM(5, 0, 0)
 
-	xs := r.IntersectSphere(*s)
+	xs := r.Intersect(s)
 	// Then xs.count = 0
 	if len(xs) != 0 {
 		t.Errorf("Expected xs.count = 0, but got %d", len(xs))
@@ -1401,7 +1409,7 @@ func TestSphereNormalAt(t *testing.T) {
 	// Given s ← sphere()
 	s := shape.UnitSphere()
 	// When n ← normal_at(s, point(1, 0, 0))
-	n := lighting.NormalAt(*s, *core.NewPoint(1, 0, 0))
+	n := lighting.NormalAt(s, *core.NewPoint(1, 0, 0))
 	// Then n = vector(1, 0, 0)
 	expected := core.NewVector(1, 0, 0)
 	if !n.IsEqual(*expected) {
@@ -1412,7 +1420,7 @@ func TestSphereNormalAt(t *testing.T) {
 	// Given s ← sphere()
 	s = shape.UnitSphere()
 	// When n ← normal_at(s, point(0, 1, 0))
-	n = lighting.NormalAt(*s, *core.NewPoint(0, 1, 0))
+	n = lighting.NormalAt(s, *core.NewPoint(0, 1, 0))
 	// Then n = vector(0, 1, 0)
 	expected = core.NewVector(0, 1, 0)
 	if !n.IsEqual(*expected) {
@@ -1423,7 +1431,7 @@ func TestSphereNormalAt(t *testing.T) {
 	// Given s ← sphere()
 	s = shape.UnitSphere()
 	// When n ← normal_at(s, point(0, 0, 1))
-	n = lighting.NormalAt(*s, *core.NewPoint(0, 0, 1))
+	n = lighting.NormalAt(s, *core.NewPoint(0, 0, 1))
 	// Then n = vector(0, 0, 1)
 	expected = core.NewVector(0, 0, 1)
 	if !n.IsEqual(*expected) {
@@ -1435,7 +1443,7 @@ func TestSphereNormalAt(t *testing.T) {
 	s = shape.UnitSphere()
 	// When n ← normal_at(s, point(√3/3, √3/3, √3/3))
 	sqrtThird := math.Sqrt(3) / 3
-	n = lighting.NormalAt(*s, *core.NewPoint(sqrtThird, sqrtThird, sqrtThird))
+	n = lighting.NormalAt(s, *core.NewPoint(sqrtThird, sqrtThird, sqrtThird))
 	// Then n = vector(√3/3, √3/3, √3/3)
 	expected = core.NewVector(sqrtThird, sqrtThird, sqrtThird)
 	if !n.IsEqual(*expected) {
@@ -1449,7 +1457,7 @@ func TestSphereNormalIsNormalized(t *testing.T) {
 	s := shape.UnitSphere()
 	// When n ← normal_at(s, point(√3/3, √3/3, √3/3))
 	sqrtThird := math.Sqrt(3) / 3
-	n := lighting.NormalAt(*s, *core.NewPoint(sqrtThird, sqrtThird, sqrtThird))
+	n := lighting.NormalAt(s, *core.NewPoint(sqrtThird, sqrtThird, sqrtThird))
 	// Then n = normalize(n)
 	normalized := n.Normalize()
 	if !n.IsEqual(*normalized) {
@@ -1471,7 +1479,7 @@ func TestComputingNormalOnTranslatedSphere(t *testing.T) {
 		core.TranslationM(0, 1, 0),
 	})
 	// When n ← normal_at(s, point(0, 1.70711, -0.70711))
-	n := lighting.NormalAt(*s, *core.NewPoint(0, 1.70711, -0.70711))
+	n := lighting.NormalAt(s, *core.NewPoint(0, 1.70711, -0.70711))
 	// Then n = vector(0, 0.70711, -0.70711)
 	expected := core.NewVector(0, 0.70711, -0.70711)
 	if !n.IsEqual(*expected) {
@@ -1491,7 +1499,7 @@ func TestComputingNormalOnTransformedSphere(t *testing.T) {
 	})
 	// When n ← normal_at(s, point(0, √2/2, -√2/2))
 	sqrtHalf := math.Sqrt(2) / 2
-	n := lighting.NormalAt(*s, *core.NewPoint(0, sqrtHalf, -sqrtHalf))
+	n := lighting.NormalAt(s, *core.NewPoint(0, sqrtHalf, -sqrtHalf))
 	// Then n = vector(0, 0.97014, -0.24254)
 	expected := core.NewVector(0, 0.97014, -0.24254)
 	if !n.IsEqual(*expected) {
@@ -1536,8 +1544,8 @@ func TestLighting(t *testing.T) {
 	// Scenario: Lighting with the eye between the light and the surface
 	eyev := core.NewVector(0, 0, -1)
 	normalv := core.NewVector(0, 0, -1)
-	light := lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
-	result := lighting.Lighting(m, light, *position, *eyev, *normalv, false)
+	light := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
+	result := lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, 1)
 	expected := color.NewColor(1.9, 1.9, 1.9)
 	if !result.IsEqual(*expected) {
 		t.Errorf("Expected lighting result = %v, but got %v", expected, result)
@@ -1547,8 +1555,8 @@ func TestLighting(t *testing.T) {
 	sqrtHalf := math.Sqrt(2) / 2
 	eyev = core.NewVector(0, sqrtHalf, -sqrtHalf)
 	normalv = core.NewVector(0, 0, -1)
-	light = lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
-	result = lighting.Lighting(m, light, *position, *eyev, *normalv, false)
+	light = lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
+	result = lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, 1)
 	expected = color.NewColor(1.0, 1.0, 1.0)
 	if !result.IsEqual(*expected) {
 		t.Errorf("Expected lighting result = %v, but got %v", expected, result)
@@ -1557,8 +1565,8 @@ func TestLighting(t *testing.T) {
 	// Scenario: Lighting with eye opposite surface, light offset 45°
 	eyev = core.NewVector(0, 0, -1)
 	normalv = core.NewVector(0, 0, -1)
-	light = lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, -10))
-	result = lighting.Lighting(m, light, *position, *eyev, *normalv, false)
+	light = lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, -10))
+	result = lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, 1)
 	expected = color.NewColor(0.7364, 0.7364, 0.7364)
 	if !result.IsEqual(*expected) {
 		t.Errorf("Expected lighting result = %v, but got %v", expected, result)
@@ -1567,8 +1575,8 @@ func TestLighting(t *testing.T) {
 	// Scenario: Lighting with eye in the path of the reflection vector
 	eyev = core.NewVector(0, -sqrtHalf, -sqrtHalf)
 	normalv = core.NewVector(0, 0, -1)
-	light = lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, -10))
-	result = lighting.Lighting(m, light, *position, *eyev, *normalv, false)
+	light = lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, -10))
+	result = lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, 1)
 	expected = color.NewColor(1.6364, 1.6364, 1.6364)
 	if !result.IsEqual(*expected) {
 		t.Errorf("Expected lighting result = %v, but got %v", expected, result)
@@ -1577,8 +1585,8 @@ func TestLighting(t *testing.T) {
 	// Scenario: Lighting with the light behind the surface
 	eyev = core.NewVector(0, 0, -1)
 	normalv = core.NewVector(0, 0, -1)
-	light = lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 10))
-	result = lighting.Lighting(m, light, *position, *eyev, *normalv, false)
+	light = lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 10))
+	result = lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, 1)
 	expected = color.NewColor(0.1, 0.1, 0.1)
 	if !result.IsEqual(*expected) {
 		t.Errorf("Expected lighting result = %v, but got %v", expected, result)
@@ -1636,9 +1644,9 @@ func TestPrepareComputations_IntersectionOnOutside(t *testing.T) {
 	// And shape ← sphere()
 	shape := shape.UnitSphere()
 	// And i ← intersection(4, shape)
-	i := rayt.NewIntersection(4, *shape)
+	i := rayt.NewIntersection(4, shape)
 	// When comps ← prepare_computations(i, r)
-	comps := scene.PrepareComputations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
 	// Then comps.inside = false
 	if comps.Inside != false {
 		t.Errorf("Expected comps.inside = false, but got %v", comps.Inside)
@@ -1655,9 +1663,9 @@ func TestPrepareComputations_IntersectionOnInside(t *testing.T) {
 	// And shape ← sphere()
 	shape := shape.UnitSphere()
 	// And i ← intersection(1, shape)
-	i := rayt.NewIntersection(1, *shape)
+	i := rayt.NewIntersection(1, shape)
 	// When comps ← prepare_computations(i, r)
-	comps := scene.PrepareComputations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
 	// Then comps.point = point(0, 0, 1)
 	expectedPoint := core.NewPoint(0, 0, 1)
 	if !comps.Point.IsEqual(*expectedPoint) {
@@ -1692,16 +1700,16 @@ func TestShadeHit_Intersection(t *testing.T) {
 	}
 
 	// And shape ← the first object in w
-	shape := w.Spheres[0]
+	shape := w.Objects[0]
 
 	// And i ← intersection(4, shape)
 	i := rayt.NewIntersection(4, shape)
 
 	// When comps ← prepare_computations(i, r)
-	comps := scene.PrepareComputations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
 
 	// And c ← shade_hit(w, comps)
-	c := scene.ShadeHit(*w, *comps)
+	c := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
 
 	// Then c = color(0.38066, 0.47583, 0.2855)
 	expected := color.NewColor(0.38066, 0.47583, 0.2855)
@@ -1716,7 +1724,7 @@ func TestShadeHit_IntersectionFromInside(t *testing.T) {
 	w := scene.DefaultWorld()
 
 	// And w.light ← point_light(point(0, 0.25, 0), color(1, 1, 1))
-	w.Light = lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0.25, 0))
+	w.Lights = []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0.25, 0))}
 
 	// And r ← ray(point(0, 0, 0), vector(0, 0, 1))
 	r := rayt.Ray{
@@ -1725,16 +1733,16 @@ func TestShadeHit_IntersectionFromInside(t *testing.T) {
 	}
 
 	// And shape ← the second object in w
-	shape := w.Spheres[1]
+	shape := w.Objects[1]
 
 	// And i ← intersection(0.5, shape)
 	i := rayt.NewIntersection(0.5, shape)
 
 	// When comps ← prepare_computations(i, r)
-	comps := scene.PrepareComputations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
 
 	// And c ← shade_hit(w, comps)
-	c := scene.ShadeHit(*w, *comps)
+	c := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
 
 	// Then c = color(0.90498, 0.90498, 0.90498)
 	expected := color.NewColor(0.90498, 0.90498, 0.90498)
@@ -1755,7 +1763,7 @@ func TestColorAt_RayMisses(t *testing.T) {
 	}
 
 	// When c ← color_at(w, r)
-	c := scene.ColorAt(*w, r)
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
 
 	// Then c = color(0, 0, 0)
 	expected := color.NewColor(0, 0, 0)
@@ -1776,7 +1784,7 @@ func TestColorAt_RayHits(t *testing.T) {
 	}
 
 	// When c ← color_at(w, r)
-	c := scene.ColorAt(*w, r)
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
 
 	// Then c = color(0.38066, 0.47583, 0.2855)
 	expected := color.NewColor(0.38066, 0.47583, 0.2855)
@@ -1792,11 +1800,11 @@ func TestColorAt_IntersectionBehindRay(t *testing.T) {
 
 	// And outer ← the first object in w
 	// And outer.material.ambient ← 1
-	w.Spheres[0].Material.Ambient = 1
+	w.Objects[0].GetMaterial().Ambient = 1
 
 	// And inner ← the second object in w
 	// And inner.material.ambient ← 1
-	w.Spheres[1].Material.Ambient = 1
+	w.Objects[1].GetMaterial().Ambient = 1
 
 	// And r ← ray(point(0, 0, 0.75), vector(0, 0, -1))
 	r := rayt.Ray{
@@ -1805,10 +1813,10 @@ func TestColorAt_IntersectionBehindRay(t *testing.T) {
 	}
 
 	// When c ← color_at(w, r)
-	c := scene.ColorAt(*w, r)
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
 
 	// Then c = inner.material.color
-	expected := w.Spheres[1].Material.Color
+	expected := w.Objects[1].GetMaterial().Color
 	if !c.IsEqual(expected) {
 		t.Errorf("Expected color_at result = %v, but got %v", expected, c)
 	}
@@ -1997,13 +2005,14 @@ func TestLightingWithSurfaceInShadow(t *testing.T) {
 	normalv := core.NewVector(0, 0, -1)
 
 	// And light ← point_light(point(0, 0, -10), color(1, 1, 1))
-	light := lighting.NewLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
+	light := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
 
 	// And in_shadow ← true
-	inShadow := true
+	// (i.e none of the light reaches the point)
+	lightIntensity := 0.0
 
 	// When result ← lighting(m, light, position, eyev, normalv, in_shadow)
-	result := lighting.Lighting(m, light, *position, *eyev, *normalv, inShadow)
+	result := lighting.Lighting(m, shape.UnitSphere(), light, *position, *eyev, *normalv, lightIntensity)
 
 	// Then result = color(0.1, 0.1, 0.1)
 	expected := color.NewColor(0.1, 0.1, 0.1)
@@ -2021,9 +2030,9 @@ func TestIsShadowed_NoShadowWhenNothingCollinear(t *testing.T) {
 	p := core.NewPoint(0, 10, 0)
 
 	// Then is_shadowed(w, p) is false
-	result := scene.IsShadowed(*w, *p)
-	if result != false {
-		t.Errorf("Expected is_shadowed(w, p) = false, but got %v", result)
+	result := scene.IsShadowed(*w, w.Lights[0], *p)
+	if result != 1 {
+		t.Errorf("Expected the whole light to reach p, but got %v", result)
 	}
 }
 
@@ -2036,9 +2045,9 @@ func TestIsShadowed_ShadowWhenObjectBetweenPointAndLight(t *testing.T) {
 	p := core.NewPoint(10, -10, 10)
 
 	// Then is_shadowed(w, p) is true
-	result := scene.IsShadowed(*w, *p)
-	if result != true {
-		t.Errorf("Expected is_shadowed(w, p) = true, but got %v", result)
+	result := scene.IsShadowed(*w, w.Lights[0], *p)
+	if result != 0 {
+		t.Errorf("Expected no light to reach p, but got %v", result)
 	}
 }
 
@@ -2051,9 +2060,9 @@ func TestIsShadowed_NoShadowWhenObjectBehindLight(t *testing.T) {
 	p := core.NewPoint(-20, 20, -20)
 
 	// Then is_shadowed(w, p) is false
-	result := scene.IsShadowed(*w, *p)
-	if result != false {
-		t.Errorf("Expected is_shadowed(w, p) = false, but got %v", result)
+	result := scene.IsShadowed(*w, w.Lights[0], *p)
+	if result != 1 {
+		t.Errorf("Expected the whole light to reach p, but got %v", result)
 	}
 }
 
@@ -2066,8 +2075,4759 @@ func TestIsShadowed_NoShadowWhenObjectBehindPoint(t *testing.T) {
 	p := core.NewPoint(-2, 2, -2)
 
 	// Then is_shadowed(w, p) is false
-	result := scene.IsShadowed(*w, *p)
-	if result != false {
-		t.Errorf("Expected is_shadowed(w, p) = false, but got %v", result)
+	result := scene.IsShadowed(*w, w.Lights[0], *p)
+	if result != 1 {
+		t.Errorf("Expected the whole light to reach p, but got %v", result)
+	}
+}
+
+/* ------------- Shapes --------------- */
+
+// testShape records the object space ray it was intersected with, so that the
+// tests can check the conversions done by the Shape abstraction
+type testShape struct {
+	Transform      core.Matrix
+	Material       material.Material
+	Parent         shape.Shape
+	savedOrigin    core.Point
+	savedDirection core.Vector
+}
+
+func newTestShape() *testShape {
+	return &testShape{Transform: *core.IdentityMatrix(), Material: material.DefaultMaterial()}
+}
+
+func (s *testShape) LocalIntersect(origin core.Point, direction core.Vector) []shape.Intersection {
+	s.savedOrigin = origin
+	s.savedDirection = direction
+	return []shape.Intersection{}
+}
+
+func (s *testShape) LocalNormalAt(p core.Point, hit shape.Intersection) core.Vector {
+	return core.Vector{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+func (s *testShape) GetTransform() core.Matrix {
+	return s.Transform
+}
+
+func (s *testShape) Bounds() shape.BoundingBox {
+	return *shape.NewBoundingBox(*core.NewPoint(-1, -1, -1), *core.NewPoint(1, 1, 1))
+}
+
+func (s *testShape) GetMaterial() *material.Material {
+	return &s.Material
+}
+
+func (s *testShape) GetParent() shape.Shape {
+	return s.Parent
+}
+
+func (s *testShape) SetParent(parent shape.Shape) {
+	s.Parent = parent
+}
+
+func (s *testShape) CastsShadow() bool {
+	return s.Parent == nil || s.Parent.CastsShadow()
+}
+
+func (s *testShape) ReceivesShadow() bool {
+	return s.Parent == nil || s.Parent.ReceivesShadow()
+}
+
+func TestShapeMaterial(t *testing.T) {
+	// Scenario: Assigning a material
+	// Given s ← test_shape()
+	s := newTestShape()
+	// And m ← material()
+	// And m.ambient ← 1
+	m := material.DefaultMaterial()
+	m.Ambient = 1
+	// When s.material ← m
+	*s.GetMaterial() = m
+	// Then s.material = m
+	if s.Material != m {
+		t.Errorf("Expected s.material = %v, but got %v", m, s.Material)
+	}
+}
+
+func TestIntersectingScaledShapeWithRay(t *testing.T) {
+	// Scenario: Intersecting a scaled shape with a ray
+	// Given r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And s ← test_shape()
+	s := newTestShape()
+	// When set_transform(s, scaling(2, 2, 2))
+	s.Transform = *core.ScaleM(2, 2, 2)
+	// And xs ← intersect(s, r)
+	r.Intersect(s)
+	// Then s.saved_ray.origin = point(0, 0, -2.5)
+	expectedOrigin := core.NewPoint(0, 0, -2.5)
+	if !s.savedOrigin.IsEqual(*expectedOrigin) {
+		t.Errorf("Expected saved_ray.origin = %v, but got %v", expectedOrigin, s.savedOrigin)
+	}
+	// And s.saved_ray.direction = vector(0, 0, 0.5)
+	expectedDirection := core.NewVector(0, 0, 0.5)
+	if !s.savedDirection.IsEqual(*expectedDirection) {
+		t.Errorf("Expected saved_ray.direction = %v, but got %v", expectedDirection, s.savedDirection)
+	}
+}
+
+func TestIntersectingTranslatedShapeWithRay(t *testing.T) {
+	// Scenario: Intersecting a translated shape with a ray
+	// Given r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And s ← test_shape()
+	s := newTestShape()
+	// When set_transform(s, translation(5, 0, 0))
+	s.Transform = *core.TranslationM(5, 0, 0)
+	// And xs ← intersect(s, r)
+	r.Intersect(s)
+	// Then s.saved_ray.origin = point(-5, 0, -5)
+	expectedOrigin := core.NewPoint(-5, 0, -5)
+	if !s.savedOrigin.IsEqual(*expectedOrigin) {
+		t.Errorf("Expected saved_ray.origin = %v, but got %v", expectedOrigin, s.savedOrigin)
+	}
+	// And s.saved_ray.direction = vector(0, 0, 1)
+	expectedDirection := core.NewVector(0, 0, 1)
+	if !s.savedDirection.IsEqual(*expectedDirection) {
+		t.Errorf("Expected saved_ray.direction = %v, but got %v", expectedDirection, s.savedDirection)
+	}
+}
+
+func TestComputingNormalOnTranslatedShape(t *testing.T) {
+	// Scenario: Computing the normal on a translated shape
+	// Given s ← test_shape()
+	s := newTestShape()
+	// When set_transform(s, translation(0, 1, 0))
+	s.Transform = *core.TranslationM(0, 1, 0)
+	// And n ← normal_at(s, point(0, 1.70711, -0.70711))
+	n := lighting.NormalAt(s, *core.NewPoint(0, 1.70711, -0.70711))
+	// Then n = vector(0, 0.70711, -0.70711)
+	expected := core.NewVector(0, 0.70711, -0.70711)
+	if !n.IsEqual(*expected) {
+		t.Errorf("Expected normal = %v, but got %v", expected, n)
+	}
+}
+
+/* ------------- Planes --------------- */
+func TestPlaneNormalIsConstant(t *testing.T) {
+	// Scenario: The normal of a plane is constant everywhere
+	// Given p ← plane()
+	p := shape.NewPlane()
+	expected := core.NewVector(0, 1, 0)
+	// When n1 ← local_normal_at(p, point(0, 0, 0))
+	// And n2 ← local_normal_at(p, point(10, 0, -10))
+	// And n3 ← local_normal_at(p, point(-5, 0, 150))
+	// Then n1 = vector(0, 1, 0), n2 = vector(0, 1, 0), n3 = vector(0, 1, 0)
+	for _, point := range []*core.Point{
+		core.NewPoint(0, 0, 0),
+		core.NewPoint(10, 0, -10),
+		core.NewPoint(-5, 0, 150),
+	} {
+		n := p.LocalNormalAt(*point, shape.Intersection{})
+		if !n.IsEqual(*expected) {
+			t.Errorf("Expected local_normal_at(p, %v) = %v, but got %v", point, expected, n)
+		}
+	}
+}
+
+func TestIntersectPlane_ParallelRay(t *testing.T) {
+	// Scenario: Intersect with a ray parallel to the plane
+	// Given p ← plane()
+	p := shape.NewPlane()
+	// And r ← ray(point(0, 10, 0), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 10, 0),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// When xs ← intersect(p, r)
+	xs := r.Intersect(p)
+	// Then xs is empty
+	if len(xs) != 0 {
+		t.Errorf("Expected xs.count = 0, but got %d", len(xs))
+	}
+}
+
+func TestIntersectPlane_CoplanarRay(t *testing.T) {
+	// Scenario: Intersect with a coplanar ray
+	// Given p ← plane()
+	p := shape.NewPlane()
+	// And r ← ray(point(0, 0, 0), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, 0),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// When xs ← intersect(p, r)
+	xs := r.Intersect(p)
+	// Then xs is empty
+	if len(xs) != 0 {
+		t.Errorf("Expected xs.count = 0, but got %d", len(xs))
+	}
+}
+
+func TestIntersectPlane_FromAboveAndBelow(t *testing.T) {
+	p := shape.NewPlane()
+
+	// Scenario: A ray intersecting a plane from above
+	// Scenario: A ray intersecting a plane from below
+	for _, r := range []rayt.Ray{
+		{Origin: *core.NewPoint(0, 1, 0), Direction: *core.NewVector(0, -1, 0)},
+		{Origin: *core.NewPoint(0, -1, 0), Direction: *core.NewVector(0, 1, 0)},
+	} {
+		// When xs ← intersect(p, r)
+		xs := r.Intersect(p)
+		// Then xs.count = 1
+		if len(xs) != 1 {
+			t.Fatalf("Expected xs.count = 1, but got %d", len(xs))
+		}
+		// And xs[0].t = 1
+		if !core.IsFloatEqual(xs[0].T, 1) {
+			t.Errorf("Expected xs[0].t = 1, but got %v", xs[0].T)
+		}
+		// And xs[0].object = p
+		if xs[0].Object != p {
+			t.Errorf("Expected xs[0].object = %v, but got %v", p, xs[0].Object)
+		}
+	}
+}
+
+func TestIsShadowed_PlaneCastsShadow(t *testing.T) {
+	// Scenario: A plane between the point and the light casts a shadow
+	// Given w ← default_world()
+	w := scene.DefaultWorld()
+	// And p ← plane() translated to y = 20
+	p := shape.NewPlane()
+	p.Transform = *core.TranslationM(0, 20, 0)
+	w.Objects = append(w.Objects, p)
+	// And w.light ← point_light(point(0, 30, 0), color(1, 1, 1))
+	w.Lights = []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 30, 0))}
+	// Then is_shadowed(w, point(0, 10, 0)) is true
+	if scene.IsShadowed(*w, w.Lights[0], *core.NewPoint(0, 10, 0)) != 0 {
+		t.Errorf("Expected is_shadowed(w, p) = true, but got false")
+	}
+}
+
+/* ------------- Cubes --------------- */
+func TestRayIntersectsCube(t *testing.T) {
+	// Scenario Outline: A ray intersects a cube
+	c := shape.NewCube()
+	examples := []struct {
+		name      string
+		origin    *core.Point
+		direction *core.Vector
+		t1, t2    float64
+	}{
+		{"+x", core.NewPoint(5, 0.5, 0), core.NewVector(-1, 0, 0), 4, 6},
+		{"-x", core.NewPoint(-5, 0.5, 0), core.NewVector(1, 0, 0), 4, 6},
+		{"+y", core.NewPoint(0.5, 5, 0), core.NewVector(0, -1, 0), 4, 6},
+		{"-y", core.NewPoint(0.5, -5, 0), core.NewVector(0, 1, 0), 4, 6},
+		{"+z", core.NewPoint(0.5, 0, 5), core.NewVector(0, 0, -1), 4, 6},
+		{"-z", core.NewPoint(0.5, 0, -5), core.NewVector(0, 0, 1), 4, 6},
+		{"inside", core.NewPoint(0, 0.5, 0), core.NewVector(0, 0, 1), -1, 1},
+	}
+
+	for _, e := range examples {
+		// When r ← ray(<origin>, <direction>)
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction}
+		// And xs ← local_intersect(c, r)
+		xs := r.Intersect(c)
+		// Then xs.count = 2
+		if len(xs) != 2 {
+			t.Errorf("%s: Expected xs.count = 2, but got %d", e.name, len(xs))
+			continue
+		}
+		// And xs[0].t = <t1>
+		// And xs[1].t = <t2>
+		if !core.IsFloatEqual(xs[0].T, e.t1) || !core.IsFloatEqual(xs[1].T, e.t2) {
+			t.Errorf("%s: Expected xs = (%v, %v), but got (%v, %v)", e.name, e.t1, e.t2, xs[0].T, xs[1].T)
+		}
+	}
+}
+
+func TestRayMissesCube(t *testing.T) {
+	// Scenario Outline: A ray misses a cube
+	c := shape.NewCube()
+	examples := []struct {
+		origin    *core.Point
+		direction *core.Vector
+	}{
+		{core.NewPoint(-2, 0, 0), core.NewVector(0.2673, 0.5345, 0.8018)},
+		{core.NewPoint(0, -2, 0), core.NewVector(0.8018, 0.2673, 0.5345)},
+		{core.NewPoint(0, 0, -2), core.NewVector(0.5345, 0.8018, 0.2673)},
+		{core.NewPoint(2, 0, 2), core.NewVector(0, 0, -1)},
+		{core.NewPoint(0, 2, 2), core.NewVector(0, -1, 0)},
+		{core.NewPoint(2, 2, 0), core.NewVector(-1, 0, 0)},
+	}
+
+	for _, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction}
+		xs := r.Intersect(c)
+		// Then xs.count = 0
+		if len(xs) != 0 {
+			t.Errorf("Expected ray %v to miss the cube, but got %d intersections", r, len(xs))
+		}
+	}
+}
+
+func TestNormalOnSurfaceOfCube(t *testing.T) {
+	// Scenario Outline: The normal on the surface of a cube
+	c := shape.NewCube()
+	examples := []struct {
+		point  *core.Point
+		normal *core.Vector
+	}{
+		{core.NewPoint(1, 0.5, -0.8), core.NewVector(1, 0, 0)},
+		{core.NewPoint(-1, -0.2, 0.9), core.NewVector(-1, 0, 0)},
+		{core.NewPoint(-0.4, 1, -0.1), core.NewVector(0, 1, 0)},
+		{core.NewPoint(0.3, -1, -0.7), core.NewVector(0, -1, 0)},
+		{core.NewPoint(-0.6, 0.3, 1), core.NewVector(0, 0, 1)},
+		{core.NewPoint(0.4, 0.4, -1), core.NewVector(0, 0, -1)},
+		{core.NewPoint(1, 1, 1), core.NewVector(1, 0, 0)},
+		{core.NewPoint(-1, -1, -1), core.NewVector(-1, 0, 0)},
+	}
+
+	for _, e := range examples {
+		// When normal ← local_normal_at(c, p)
+		normal := c.LocalNormalAt(*e.point, shape.Intersection{})
+		if !normal.IsEqual(*e.normal) {
+			t.Errorf("Expected normal at %v = %v, but got %v", e.point, e.normal, normal)
+		}
+	}
+}
+
+func TestBoundingBoxSlab(t *testing.T) {
+	// Given box ← bounding_box(min=point(-1, -2, 0), max=point(3, 2, 4))
+	box := shape.NewBoundingBox(*core.NewPoint(-1, -2, 0), *core.NewPoint(3, 2, 4))
+
+	// A ray along +x through the box enters at x = -1 and leaves at x = 3
+	tmin, tmax, hit := box.Slab(*core.NewPoint(-5, 0, 2), *core.NewVector(1, 0, 0))
+	if !hit || !core.IsFloatEqual(tmin, 4) || !core.IsFloatEqual(tmax, 8) {
+		t.Errorf("Expected slab = (4, 8, true), but got (%v, %v, %v)", tmin, tmax, hit)
+	}
+
+	// A ray passing above the box misses it
+	if box.Intersects(*core.NewPoint(-5, 3, 2), *core.NewVector(1, 0, 0)) {
+		t.Errorf("Expected ray above the box to miss it")
+	}
+}
+
+/* ------------- Cylinders --------------- */
+func TestRayMissesCylinder(t *testing.T) {
+	// Scenario Outline: A ray misses a cylinder
+	cyl := shape.NewCylinder()
+	examples := []struct {
+		origin    *core.Point
+		direction *core.Vector
+	}{
+		{core.NewPoint(1, 0, 0), core.NewVector(0, 1, 0)},
+		{core.NewPoint(0, 0, 0), core.NewVector(0, 1, 0)},
+		{core.NewPoint(0, 0, -5), core.NewVector(1, 1, 1)},
+	}
+
+	for _, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cyl)
+		// Then xs.count = 0
+		if len(xs) != 0 {
+			t.Errorf("Expected ray %v to miss the cylinder, but got %d intersections", r, len(xs))
+		}
+	}
+}
+
+func TestRayStrikesCylinder(t *testing.T) {
+	// Scenario Outline: A ray strikes a cylinder
+	cyl := shape.NewCylinder()
+	examples := []struct {
+		origin    *core.Point
+		direction *core.Vector
+		t0, t1    float64
+	}{
+		{core.NewPoint(1, 0, -5), core.NewVector(0, 0, 1), 5, 5},
+		{core.NewPoint(0, 0, -5), core.NewVector(0, 0, 1), 4, 6},
+		{core.NewPoint(0.5, 0, -5), core.NewVector(0.1, 1, 1), 6.80798, 7.08872},
+	}
+
+	for _, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cyl)
+		if len(xs) != 2 {
+			t.Errorf("Expected xs.count = 2, but got %d", len(xs))
+			continue
+		}
+		if !core.IsFloatEqual(xs[0].T, e.t0) || !core.IsFloatEqual(xs[1].T, e.t1) {
+			t.Errorf("Expected xs = (%v, %v), but got (%v, %v)", e.t0, e.t1, xs[0].T, xs[1].T)
+		}
+	}
+}
+
+func TestNormalOnCylinder(t *testing.T) {
+	// Scenario Outline: Normal vector on a cylinder
+	cyl := shape.NewCylinder()
+	examples := []struct {
+		point  *core.Point
+		normal *core.Vector
+	}{
+		{core.NewPoint(1, 0, 0), core.NewVector(1, 0, 0)},
+		{core.NewPoint(0, 5, -1), core.NewVector(0, 0, -1)},
+		{core.NewPoint(0, -2, 1), core.NewVector(0, 0, 1)},
+		{core.NewPoint(-1, 1, 0), core.NewVector(-1, 0, 0)},
+	}
+
+	for _, e := range examples {
+		n := cyl.LocalNormalAt(*e.point, shape.Intersection{})
+		if !n.IsEqual(*e.normal) {
+			t.Errorf("Expected normal at %v = %v, but got %v", e.point, e.normal, n)
+		}
+	}
+}
+
+func TestDefaultCylinderIsInfiniteAndOpen(t *testing.T) {
+	// Scenario: The default minimum and maximum for a cylinder
+	// Scenario: The default closed value for a cylinder
+	cyl := shape.NewCylinder()
+	if !math.IsInf(cyl.Minimum, -1) || !math.IsInf(cyl.Maximum, 1) {
+		t.Errorf("Expected cyl limits = (-inf, inf), but got (%v, %v)", cyl.Minimum, cyl.Maximum)
+	}
+	if cyl.Closed {
+		t.Errorf("Expected cyl.closed = false, but got true")
+	}
+}
+
+func TestIntersectingConstrainedCylinder(t *testing.T) {
+	// Scenario Outline: Intersecting a constrained cylinder
+	cyl := shape.NewCylinder()
+	cyl.Minimum = 1
+	cyl.Maximum = 2
+	examples := []struct {
+		point     *core.Point
+		direction *core.Vector
+		count     int
+	}{
+		{core.NewPoint(0, 1.5, 0), core.NewVector(0.1, 1, 0), 0},
+		{core.NewPoint(0, 3, -5), core.NewVector(0, 0, 1), 0},
+		{core.NewPoint(0, 0, -5), core.NewVector(0, 0, 1), 0},
+		{core.NewPoint(0, 2, -5), core.NewVector(0, 0, 1), 0},
+		{core.NewPoint(0, 1, -5), core.NewVector(0, 0, 1), 0},
+		{core.NewPoint(0, 1.5, -2), core.NewVector(0, 0, 1), 2},
+	}
+
+	for i, e := range examples {
+		r := rayt.Ray{Origin: *e.point, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cyl)
+		if len(xs) != e.count {
+			t.Errorf("%d: Expected xs.count = %d, but got %d", i+1, e.count, len(xs))
+		}
+	}
+}
+
+func TestIntersectingCapsOfClosedCylinder(t *testing.T) {
+	// Scenario Outline: Intersecting the caps of a closed cylinder
+	cyl := shape.NewCylinder()
+	cyl.Minimum = 1
+	cyl.Maximum = 2
+	cyl.Closed = true
+	examples := []struct {
+		point     *core.Point
+		direction *core.Vector
+		count     int
+	}{
+		{core.NewPoint(0, 3, 0), core.NewVector(0, -1, 0), 2},
+		{core.NewPoint(0, 3, -2), core.NewVector(0, -1, 2), 2},
+		{core.NewPoint(0, 4, -2), core.NewVector(0, -1, 1), 2}, // corner case
+		{core.NewPoint(0, 0, -2), core.NewVector(0, 1, 2), 2},
+		{core.NewPoint(0, -1, -2), core.NewVector(0, 1, 1), 2}, // corner case
+	}
+
+	for i, e := range examples {
+		r := rayt.Ray{Origin: *e.point, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cyl)
+		if len(xs) != e.count {
+			t.Errorf("%d: Expected xs.count = %d, but got %d", i+1, e.count, len(xs))
+		}
+	}
+}
+
+func TestNormalOnCylinderEndCaps(t *testing.T) {
+	// Scenario Outline: The normal vector on a cylinder's end caps
+	cyl := shape.NewCylinder()
+	cyl.Minimum = 1
+	cyl.Maximum = 2
+	cyl.Closed = true
+	examples := []struct {
+		point  *core.Point
+		normal *core.Vector
+	}{
+		{core.NewPoint(0, 1, 0), core.NewVector(0, -1, 0)},
+		{core.NewPoint(0.5, 1, 0), core.NewVector(0, -1, 0)},
+		{core.NewPoint(0, 1, 0.5), core.NewVector(0, -1, 0)},
+		{core.NewPoint(0, 2, 0), core.NewVector(0, 1, 0)},
+		{core.NewPoint(0.5, 2, 0), core.NewVector(0, 1, 0)},
+		{core.NewPoint(0, 2, 0.5), core.NewVector(0, 1, 0)},
+	}
+
+	for _, e := range examples {
+		n := cyl.LocalNormalAt(*e.point, shape.Intersection{})
+		if !n.IsEqual(*e.normal) {
+			t.Errorf("Expected normal at %v = %v, but got %v", e.point, e.normal, n)
+		}
+	}
+}
+
+/* ------------- Cones --------------- */
+func TestIntersectingConeWithRay(t *testing.T) {
+	// Scenario Outline: Intersecting a cone with a ray
+	cone := shape.NewCone()
+	examples := []struct {
+		origin    *core.Point
+		direction *core.Vector
+		t0, t1    float64
+	}{
+		{core.NewPoint(0, 0, -5), core.NewVector(0, 0, 1), 5, 5},
+		{core.NewPoint(0, 0, -5), core.NewVector(1, 1, 1), 8.66025, 8.66025},
+		{core.NewPoint(1, 1, -5), core.NewVector(-0.5, -1, 1), 4.55006, 49.44994},
+	}
+
+	for _, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cone)
+		if len(xs) != 2 {
+			t.Errorf("Expected xs.count = 2, but got %d", len(xs))
+			continue
+		}
+		// the book compares these with a looser tolerance than EPSILON
+		if math.Abs(xs[0].T-e.t0) > 1e-4 || math.Abs(xs[1].T-e.t1) > 1e-4 {
+			t.Errorf("Expected xs = (%v, %v), but got (%v, %v)", e.t0, e.t1, xs[0].T, xs[1].T)
+		}
+	}
+}
+
+func TestIntersectingConeWithRayParallelToOneHalf(t *testing.T) {
+	// Scenario: Intersecting a cone with a ray parallel to one of its halves
+	cone := shape.NewCone()
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -1),
+		Direction: *core.NewVector(0, 1, 1).Normalize(),
+	}
+	xs := r.Intersect(cone)
+	// Then xs.count = 1
+	if len(xs) != 1 {
+		t.Fatalf("Expected xs.count = 1, but got %d", len(xs))
+	}
+	// And xs[0].t = 0.35355
+	if !core.IsFloatEqual(xs[0].T, 0.35355) {
+		t.Errorf("Expected xs[0].t = 0.35355, but got %v", xs[0].T)
+	}
+}
+
+func TestIntersectingConeEndCaps(t *testing.T) {
+	// Scenario Outline: Intersecting a cone's end caps
+	cone := shape.NewCone()
+	cone.Minimum = -0.5
+	cone.Maximum = 0.5
+	cone.Closed = true
+	examples := []struct {
+		origin    *core.Point
+		direction *core.Vector
+		count     int
+	}{
+		{core.NewPoint(0, 0, -5), core.NewVector(0, 1, 0), 0},
+		{core.NewPoint(0, 0, -0.25), core.NewVector(0, 1, 1), 2},
+		{core.NewPoint(0, 0, -0.25), core.NewVector(0, 1, 0), 4},
+	}
+
+	for i, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction.Normalize()}
+		xs := r.Intersect(cone)
+		if len(xs) != e.count {
+			t.Errorf("%d: Expected xs.count = %d, but got %d", i+1, e.count, len(xs))
+		}
+	}
+}
+
+func TestNormalOnCone(t *testing.T) {
+	// Scenario Outline: Computing the normal vector on a cone
+	cone := shape.NewCone()
+	examples := []struct {
+		point  *core.Point
+		normal *core.Vector
+	}{
+		{core.NewPoint(0, 0, 0), core.NewVector(0, 0, 0)},
+		{core.NewPoint(1, 1, 1), core.NewVector(1, -math.Sqrt(2), 1)},
+		{core.NewPoint(-1, -1, 0), core.NewVector(-1, 1, 0)},
+	}
+
+	for _, e := range examples {
+		n := cone.LocalNormalAt(*e.point, shape.Intersection{})
+		if !n.IsEqual(*e.normal) {
+			t.Errorf("Expected normal at %v = %v, but got %v", e.point, e.normal, n)
+		}
+	}
+}
+
+/* ------------- Triangles --------------- */
+func TestConstructingTriangle(t *testing.T) {
+	// Scenario: Constructing a triangle
+	// Given p1 ← point(0, 1, 0)
+	// And p2 ← point(-1, 0, 0)
+	// And p3 ← point(1, 0, 0)
+	p1 := core.NewPoint(0, 1, 0)
+	p2 := core.NewPoint(-1, 0, 0)
+	p3 := core.NewPoint(1, 0, 0)
+	// And t ← triangle(p1, p2, p3)
+	tri := shape.NewTriangle(*p1, *p2, *p3)
+	// Then t.e1 = vector(-1, -1, 0)
+	if !tri.E1.IsEqual(*core.NewVector(-1, -1, 0)) {
+		t.Errorf("Expected t.e1 = (-1, -1, 0), but got %v", tri.E1)
+	}
+	// And t.e2 = vector(1, -1, 0)
+	if !tri.E2.IsEqual(*core.NewVector(1, -1, 0)) {
+		t.Errorf("Expected t.e2 = (1, -1, 0), but got %v", tri.E2)
+	}
+	// And t.normal = vector(0, 0, -1)
+	if !tri.Normal.IsEqual(*core.NewVector(0, 0, -1)) {
+		t.Errorf("Expected t.normal = (0, 0, -1), but got %v", tri.Normal)
+	}
+}
+
+func TestNormalOnTriangle(t *testing.T) {
+	// Scenario: Finding the normal on a triangle
+	tri := shape.NewTriangle(*core.NewPoint(0, 1, 0), *core.NewPoint(-1, 0, 0), *core.NewPoint(1, 0, 0))
+	for _, p := range []*core.Point{
+		core.NewPoint(0, 0.5, 0),
+		core.NewPoint(-0.5, 0.75, 0),
+		core.NewPoint(0.5, 0.25, 0),
+	} {
+		n := tri.LocalNormalAt(*p, shape.Intersection{})
+		if !n.IsEqual(tri.Normal) {
+			t.Errorf("Expected normal at %v = %v, but got %v", p, tri.Normal, n)
+		}
+	}
+}
+
+func TestRayMissesTriangle(t *testing.T) {
+	tri := shape.NewTriangle(*core.NewPoint(0, 1, 0), *core.NewPoint(-1, 0, 0), *core.NewPoint(1, 0, 0))
+	examples := []struct {
+		name      string
+		origin    *core.Point
+		direction *core.Vector
+	}{
+		// Scenario: Intersecting a ray parallel to the triangle
+		{"parallel", core.NewPoint(0, -1, -2), core.NewVector(0, 1, 0)},
+		// Scenario: A ray misses the p1-p3 edge
+		{"p1-p3 edge", core.NewPoint(1, 1, -2), core.NewVector(0, 0, 1)},
+		// Scenario: A ray misses the p1-p2 edge
+		{"p1-p2 edge", core.NewPoint(-1, 1, -2), core.NewVector(0, 0, 1)},
+		// Scenario: A ray misses the p2-p3 edge
+		{"p2-p3 edge", core.NewPoint(0, -1, -2), core.NewVector(0, 0, 1)},
+	}
+
+	for _, e := range examples {
+		r := rayt.Ray{Origin: *e.origin, Direction: *e.direction}
+		xs := r.Intersect(tri)
+		// Then xs is empty
+		if len(xs) != 0 {
+			t.Errorf("%s: Expected xs.count = 0, but got %d", e.name, len(xs))
+		}
+	}
+}
+
+func TestRayStrikesTriangle(t *testing.T) {
+	// Scenario: A ray strikes a triangle
+	tri := shape.NewTriangle(*core.NewPoint(0, 1, 0), *core.NewPoint(-1, 0, 0), *core.NewPoint(1, 0, 0))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0.5, -2),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	xs := r.Intersect(tri)
+	// Then xs.count = 1
+	if len(xs) != 1 {
+		t.Fatalf("Expected xs.count = 1, but got %d", len(xs))
+	}
+	// And xs[0].t = 2
+	if !core.IsFloatEqual(xs[0].T, 2) {
+		t.Errorf("Expected xs[0].t = 2, but got %v", xs[0].T)
+	}
+}
+
+func newTestSmoothTriangle() *shape.SmoothTriangle {
+	// Given p1 ← point(0, 1, 0)
+	// And p2 ← point(-1, 0, 0)
+	// And p3 ← point(1, 0, 0)
+	// And n1 ← vector(0, 1, 0)
+	// And n2 ← vector(-1, 0, 0)
+	// And n3 ← vector(1, 0, 0)
+	// When tri ← smooth_triangle(p1, p2, p3, n1, n2, n3)
+	return shape.NewSmoothTriangle(
+		*core.NewPoint(0, 1, 0), *core.NewPoint(-1, 0, 0), *core.NewPoint(1, 0, 0),
+		*core.NewVector(0, 1, 0), *core.NewVector(-1, 0, 0), *core.NewVector(1, 0, 0),
+	)
+}
+
+func TestIntersectionEncapsulatesUV(t *testing.T) {
+	// Scenario: An intersection can encapsulate `u` and `v`
+	// Given s ← triangle(point(0, 1, 0), point(-1, 0, 0), point(1, 0, 0))
+	s := shape.NewTriangle(*core.NewPoint(0, 1, 0), *core.NewPoint(-1, 0, 0), *core.NewPoint(1, 0, 0))
+	// When i ← intersection_with_uv(3.5, s, 0.2, 0.4)
+	i := rayt.NewIntersectionWithUV(3.5, s, 0.2, 0.4)
+	// Then i.u = 0.2
+	// And i.v = 0.4
+	if i.U != 0.2 || i.V != 0.4 {
+		t.Errorf("Expected (i.u, i.v) = (0.2, 0.4), but got (%v, %v)", i.U, i.V)
+	}
+}
+
+func TestIntersectionWithSmoothTriangleStoresUV(t *testing.T) {
+	// Scenario: An intersection with a smooth triangle stores u/v
+	tri := newTestSmoothTriangle()
+	// When r ← ray(point(-0.2, 0.3, -2), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(-0.2, 0.3, -2),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And xs ← local_intersect(tri, r)
+	xs := r.Intersect(tri)
+	if len(xs) != 1 {
+		t.Fatalf("Expected xs.count = 1, but got %d", len(xs))
+	}
+	// Then xs[0].u = 0.45
+	// And xs[0].v = 0.25
+	if !core.IsFloatEqual(xs[0].U, 0.45) || !core.IsFloatEqual(xs[0].V, 0.25) {
+		t.Errorf("Expected (u, v) = (0.45, 0.25), but got (%v, %v)", xs[0].U, xs[0].V)
+	}
+}
+
+func TestSmoothTriangleInterpolatesNormal(t *testing.T) {
+	// Scenario: A smooth triangle uses u/v to interpolate the normal
+	tri := newTestSmoothTriangle()
+	// When i ← intersection_with_uv(1, tri, 0.45, 0.25)
+	i := rayt.NewIntersectionWithUV(1, tri, 0.45, 0.25)
+	// And n ← normal_at(tri, point(0, 0, 0), i)
+	n := lighting.NormalAtHit(i, *core.NewPoint(0, 0, 0))
+	// Then n = vector(-0.5547, 0.83205, 0)
+	expected := core.NewVector(-0.5547, 0.83205, 0)
+	if !n.IsEqual(*expected) {
+		t.Errorf("Expected normal = %v, but got %v", expected, n)
+	}
+}
+
+func TestPrepareComputations_SmoothTriangle(t *testing.T) {
+	// Scenario: Preparing the normal on a smooth triangle
+	tri := newTestSmoothTriangle()
+	// When i ← intersection_with_uv(1, tri, 0.45, 0.25)
+	i := rayt.NewIntersectionWithUV(1, tri, 0.45, 0.25)
+	// And r ← ray(point(-0.2, 0.3, -2), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(-0.2, 0.3, -2),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+	// Then comps.normalv = vector(-0.5547, 0.83205, 0)
+	expected := core.NewVector(-0.5547, 0.83205, 0)
+	if !comps.NormalV.IsEqual(*expected) {
+		t.Errorf("Expected comps.normalv = %v, but got %v", expected, comps.NormalV)
+	}
+}
+
+/* ------------- OBJ files --------------- */
+func TestLoadOBJ_IgnoresUnrecognizedLines(t *testing.T) {
+	// Scenario: Ignoring unrecognized lines
+	// Given gibberish ← a file containing:
+	gibberish := `There was a young lady named Bright
+who traveled much faster than light.
+She set out one day
+in a relative way,
+and came back the previous night.`
+	// When parser ← parse_obj_file(gibberish)
+	obj, err := scene.LoadOBJ(strings.NewReader(gibberish))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	// Then parser should have ignored 5 lines
+	expected := []int{1, 2, 3, 4, 5}
+	if fmt.Sprint(obj.IgnoredLines) != fmt.Sprint(expected) {
+		t.Errorf("Expected ignored lines = %v, but got %v", expected, obj.IgnoredLines)
+	}
+}
+
+func TestLoadOBJ_ReportsMalformedLines(t *testing.T) {
+	file := `v -1 1 0
+v 1 zero 0
+v 1 0 0
+
+f 1 2 7
+vn 0 1
+f 1 2 3`
+	obj, err := scene.LoadOBJ(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	// the bad vertex, the short normal and both faces (which refer to vertices
+	// that do not exist, since the second vertex was dropped) are reported;
+	// the blank line is not
+	expected := []int{2, 5, 6, 7}
+	if fmt.Sprint(obj.IgnoredLines) != fmt.Sprint(expected) {
+		t.Errorf("Expected ignored lines = %v, but got %v", expected, obj.IgnoredLines)
+	}
+	if len(obj.Vertices) != 2 || len(obj.DefaultGroup) != 0 {
+		t.Errorf("Expected 2 vertices and no triangles, but got %d and %d", len(obj.Vertices), len(obj.DefaultGroup))
+	}
+}
+
+func TestLoadOBJ_VertexRecords(t *testing.T) {
+	// Scenario: Vertex records
+	file := `v -1 1 0
+v -1.0000 0.5000 0.0000
+v 1 0 0
+v 1 1 0`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	expected := []*core.Point{
+		core.NewPoint(-1, 1, 0),
+		core.NewPoint(-1, 0.5, 0),
+		core.NewPoint(1, 0, 0),
+		core.NewPoint(1, 1, 0),
+	}
+	if len(obj.Vertices) != len(expected) {
+		t.Fatalf("Expected %d vertices, but got %d", len(expected), len(obj.Vertices))
+	}
+	for i, v := range expected {
+		// Then parser.vertices[i+1] = v
+		if !obj.Vertices[i].IsEqual(*v) {
+			t.Errorf("Expected vertices[%d] = %v, but got %v", i+1, v, obj.Vertices[i])
+		}
+	}
+}
+
+func TestLoadOBJ_TriangleFaces(t *testing.T) {
+	// Scenario: Parsing triangle faces
+	file := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+
+f 1 2 3
+f 1 3 4`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	// And g ← parser.default_group
+	g := obj.DefaultGroup
+	if len(g) != 2 {
+		t.Fatalf("Expected 2 triangles, but got %d", len(g))
+	}
+	// Then t1.p1 = parser.vertices[1], t1.p2 = parser.vertices[2], ...
+	t1 := g[0].(*shape.Triangle)
+	t2 := g[1].(*shape.Triangle)
+	if t1.P1 != obj.Vertices[0] || t1.P2 != obj.Vertices[1] || t1.P3 != obj.Vertices[2] {
+		t.Errorf("Unexpected t1 corners: %v, %v, %v", t1.P1, t1.P2, t1.P3)
+	}
+	if t2.P1 != obj.Vertices[0] || t2.P2 != obj.Vertices[2] || t2.P3 != obj.Vertices[3] {
+		t.Errorf("Unexpected t2 corners: %v, %v, %v", t2.P1, t2.P2, t2.P3)
+	}
+}
+
+func TestLoadOBJ_TriangulatingPolygons(t *testing.T) {
+	// Scenario: Triangulating polygons
+	file := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+v 0 2 0
+
+f 1 2 3 4 5`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	g := obj.DefaultGroup
+	if len(g) != 3 {
+		t.Fatalf("Expected 3 triangles, but got %d", len(g))
+	}
+	for i, s := range g {
+		tri := s.(*shape.Triangle)
+		if tri.P1 != obj.Vertices[0] || tri.P2 != obj.Vertices[i+1] || tri.P3 != obj.Vertices[i+2] {
+			t.Errorf("Unexpected corners for triangle %d: %v, %v, %v", i+1, tri.P1, tri.P2, tri.P3)
+		}
+	}
+}
+
+func TestLoadOBJ_NamedGroups(t *testing.T) {
+	// Scenario: Triangles in groups
+	file := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+
+g FirstGroup
+f 1 2 3
+g SecondGroup
+f 1 3 4`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	// And g1 ← "FirstGroup" from parser
+	// And g2 ← "SecondGroup" from parser
+	g1 := obj.Groups["FirstGroup"]
+	g2 := obj.Groups["SecondGroup"]
+	if len(g1) != 1 || len(g2) != 1 || len(obj.DefaultGroup) != 0 {
+		t.Fatalf("Expected one triangle in each group, but got %d and %d", len(g1), len(g2))
+	}
+	t2 := g2[0].(*shape.Triangle)
+	if t2.P1 != obj.Vertices[0] || t2.P2 != obj.Vertices[2] || t2.P3 != obj.Vertices[3] {
+		t.Errorf("Unexpected t2 corners: %v, %v, %v", t2.P1, t2.P2, t2.P3)
+	}
+}
+
+func TestLoadOBJ_FacesWithNormals(t *testing.T) {
+	// Scenario: Vertex normal records
+	// Scenario: Faces with normals
+	file := `v 0 1 0
+v -1 0 0
+v 1 0 0
+
+vn -1 0 0
+vn 1 0 0
+vn 0 1 0
+
+f 1//3 2//1 3//2
+f 1/0/3 2/102/1 3/14/2`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	if len(obj.Normals) != 3 || !obj.Normals[2].IsEqual(*core.NewVector(0, 1, 0)) {
+		t.Fatalf("Unexpected normals: %v", obj.Normals)
+	}
+	g := obj.DefaultGroup
+	if len(g) != 2 {
+		t.Fatalf("Expected 2 triangles, but got %d", len(g))
+	}
+	for _, s := range g {
+		tri, ok := s.(*shape.SmoothTriangle)
+		if !ok {
+			t.Fatalf("Expected a smooth triangle, but got %T", s)
+		}
+		if tri.P1 != obj.Vertices[0] || tri.N1 != obj.Normals[2] ||
+			tri.P2 != obj.Vertices[1] || tri.N2 != obj.Normals[0] ||
+			tri.P3 != obj.Vertices[2] || tri.N3 != obj.Normals[1] {
+			t.Errorf("Unexpected smooth triangle: %+v", tri)
+		}
+	}
+}
+
+/* ------------- Groups --------------- */
+func TestCreatingNewGroup(t *testing.T) {
+	// Scenario: Creating a new group
+	// Given g ← group()
+	g := shape.NewGroup()
+	// Then g.transform = identity_matrix
+	if !g.Transform.IsEqual(*core.IdentityMatrix()) {
+		t.Errorf("Expected g.transform = identity_matrix, but got %v", g.Transform.Value)
+	}
+	// And g is empty
+	if len(g.Children) != 0 {
+		t.Errorf("Expected g to be empty, but got %d children", len(g.Children))
+	}
+}
+
+func TestShapeHasParentAttribute(t *testing.T) {
+	// Scenario: A shape has a parent attribute
+	// Given s ← test_shape()
+	s := newTestShape()
+	// Then s.parent is nothing
+	if s.GetParent() != nil {
+		t.Errorf("Expected s.parent to be nil, but got %v", s.GetParent())
+	}
+}
+
+func TestAddingChildToGroup(t *testing.T) {
+	// Scenario: Adding a child to a group
+	// Given g ← group()
+	g := shape.NewGroup()
+	// And s ← test_shape()
+	s := newTestShape()
+	// When add_child(g, s)
+	g.AddChild(s)
+	// Then g is not empty
+	// And g includes s
+	if len(g.Children) != 1 || g.Children[0] != s {
+		t.Errorf("Expected g to contain s, but got %v", g.Children)
+	}
+	// And s.parent = g
+	if s.GetParent() != g {
+		t.Errorf("Expected s.parent = g, but got %v", s.GetParent())
+	}
+}
+
+func TestIntersectingRayWithEmptyGroup(t *testing.T) {
+	// Scenario: Intersecting a ray with an empty group
+	g := shape.NewGroup()
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, 0),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	xs := r.Intersect(g)
+	// Then xs is empty
+	if len(xs) != 0 {
+		t.Errorf("Expected xs.count = 0, but got %d", len(xs))
+	}
+}
+
+func TestIntersectingRayWithNonemptyGroup(t *testing.T) {
+	// Scenario: Intersecting a ray with a nonempty group
+	// Given g ← group()
+	g := shape.NewGroup()
+	// And s1 ← sphere()
+	s1 := shape.UnitSphere()
+	// And s2 ← sphere()
+	// And set_transform(s2, translation(0, 0, -3))
+	s2 := shape.UnitSphere()
+	s2.Transform = *core.TranslationM(0, 0, -3)
+	// And s3 ← sphere()
+	// And set_transform(s3, translation(5, 0, 0))
+	s3 := shape.UnitSphere()
+	s3.Transform = *core.TranslationM(5, 0, 0)
+	// And add_child(g, s1), add_child(g, s2), add_child(g, s3)
+	g.AddChild(s1, s2, s3)
+	// When r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And xs ← local_intersect(g, r)
+	xs := g.LocalIntersect(r.Origin, r.Direction)
+	// Then xs.count = 4
+	if len(xs) != 4 {
+		t.Fatalf("Expected xs.count = 4, but got %d", len(xs))
+	}
+	// And xs[0].object = s2, xs[1].object = s2, xs[2].object = s1, xs[3].object = s1
+	expected := []shape.Shape{s2, s2, s1, s1}
+	for i := range expected {
+		if xs[i].Object != expected[i] {
+			t.Errorf("Expected xs[%d].object = %v, but got %v", i, expected[i], xs[i].Object)
+		}
+	}
+}
+
+func TestIntersectingTransformedGroup(t *testing.T) {
+	// Scenario: Intersecting a transformed group
+	// Given g ← group()
+	// And set_transform(g, scaling(2, 2, 2))
+	g := shape.NewGroup()
+	g.Transform = *core.ScaleM(2, 2, 2)
+	// And s ← sphere()
+	// And set_transform(s, translation(5, 0, 0))
+	s := shape.UnitSphere()
+	s.Transform = *core.TranslationM(5, 0, 0)
+	// And add_child(g, s)
+	g.AddChild(s)
+	// When r ← ray(point(10, 0, -10), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(10, 0, -10),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// And xs ← intersect(g, r)
+	xs := r.Intersect(g)
+	// Then xs.count = 2
+	if len(xs) != 2 {
+		t.Errorf("Expected xs.count = 2, but got %d", len(xs))
+	}
+}
+
+// g1 (rotated) contains g2 (scaled by g2Scale) which contains s (translated)
+func newNestedGroupSphere(g2Scale *core.Matrix) *shape.Sphere {
+	g1 := shape.NewGroup()
+	g1.Transform = *core.RotateYM(math.Pi / 2)
+	g2 := shape.NewGroup()
+	g2.Transform = *g2Scale
+	g1.AddChild(g2)
+	s := shape.UnitSphere()
+	s.Transform = *core.TranslationM(5, 0, 0)
+	g2.AddChild(s)
+	return s
+}
+
+func TestConvertingPointFromWorldToObjectSpace(t *testing.T) {
+	// Scenario: Converting a point from world to object space
+	s := newNestedGroupSphere(core.ScaleM(2, 2, 2))
+	// When p ← world_to_object(s, point(-2, 0, -10))
+	p := shape.WorldToObject(s, *core.NewPoint(-2, 0, -10))
+	// Then p = point(0, 0, -1)
+	expected := core.NewPoint(0, 0, -1)
+	if !p.IsEqual(*expected) {
+		t.Errorf("Expected p = %v, but got %v", expected, p)
+	}
+}
+
+func TestConvertingNormalFromObjectToWorldSpace(t *testing.T) {
+	// Scenario: Converting a normal from object to world space
+	s := newNestedGroupSphere(core.ScaleM(1, 2, 3))
+	// When n ← normal_to_world(s, vector(√3/3, √3/3, √3/3))
+	sqrtThird := math.Sqrt(3) / 3
+	n := shape.NormalToWorld(s, *core.NewVector(sqrtThird, sqrtThird, sqrtThird))
+	// Then n = vector(0.2857, 0.4286, -0.8571)
+	expected := core.NewVector(0.2857, 0.4286, -0.8571)
+	if math.Abs(n.X-expected.X) > 1e-4 || math.Abs(n.Y-expected.Y) > 1e-4 || math.Abs(n.Z-expected.Z) > 1e-4 {
+		t.Errorf("Expected n = %v, but got %v", expected, n)
+	}
+}
+
+func TestFindingNormalOnChildObject(t *testing.T) {
+	// Scenario: Finding the normal on a child object
+	s := newNestedGroupSphere(core.ScaleM(1, 2, 3))
+	// When n ← normal_at(s, point(1.7321, 1.1547, -5.5774))
+	n := lighting.NormalAt(s, *core.NewPoint(1.7321, 1.1547, -5.5774))
+	// Then n = vector(0.2857, 0.4286, -0.8571)
+	expected := core.NewVector(0.2857, 0.4286, -0.8571)
+	if math.Abs(n.X-expected.X) > 1e-4 || math.Abs(n.Y-expected.Y) > 1e-4 || math.Abs(n.Z-expected.Z) > 1e-4 {
+		t.Errorf("Expected n = %v, but got %v", expected, n)
+	}
+}
+
+func TestRenderingGroupInWorld(t *testing.T) {
+	// Moving a group moves all of its children: once the group holding the
+	// default world spheres is moved out of the way, the ray misses them
+	w := scene.DefaultWorld()
+	g := shape.NewGroup()
+	g.AddChild(w.Objects...)
+	w.Objects = []shape.Shape{g}
+
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
+	expected := color.NewColor(0.38066, 0.47583, 0.2855)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected color_at = %v, but got %v", expected, c)
+	}
+
+	g.Transform = *core.TranslationM(0, 5, 0)
+	c = scene.ColorAt(*w, r, scene.MaxReflectionDepth)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected color_at = %v, but got %v", color.Black, c)
+	}
+}
+
+func TestConvertingOBJFileToGroup(t *testing.T) {
+	// Scenario: Converting an OBJ file to a group
+	file := `v -1 1 0
+v -1 0 0
+v 1 0 0
+v 1 1 0
+
+g FirstGroup
+f 1 2 3
+g SecondGroup
+f 1 3 4`
+	obj, _ := scene.LoadOBJ(strings.NewReader(file))
+	// When g ← obj_to_group(parser)
+	g := obj.ToGroup()
+	// Then g includes "FirstGroup" from parser
+	// And g includes "SecondGroup" from parser
+	if len(g.Children) != 2 {
+		t.Fatalf("Expected 2 child groups, but got %d", len(g.Children))
+	}
+	for i, name := range []string{"FirstGroup", "SecondGroup"} {
+		child := g.Children[i].(*shape.Group)
+		if child.Children[0] != obj.Groups[name][0] {
+			t.Errorf("Expected child %d to contain the triangle of %s", i, name)
+		}
+		if child.GetParent() != g {
+			t.Errorf("Expected %s's parent to be g", name)
+		}
+	}
+}
+
+/* ------------- Bounding volume hierarchy --------------- */
+func TestBoundingBoxAddPointsAndMerge(t *testing.T) {
+	// Scenario: Adding points to an empty bounding box
+	box := shape.EmptyBoundingBox()
+	box.AddPoint(*core.NewPoint(-5, 2, 0))
+	box.AddPoint(*core.NewPoint(7, 0, -3))
+	if !box.Min.IsEqual(*core.NewPoint(-5, 0, -3)) || !box.Max.IsEqual(*core.NewPoint(7, 2, 0)) {
+		t.Errorf("Expected box = (-5, 0, -3)..(7, 2, 0), but got %v..%v", box.Min, box.Max)
+	}
+
+	// Scenario: Adding one bounding box to another
+	box1 := shape.NewBoundingBox(*core.NewPoint(-5, -2, 0), *core.NewPoint(7, 4, 4))
+	box2 := shape.NewBoundingBox(*core.NewPoint(8, -7, -2), *core.NewPoint(14, 2, 8))
+	box1.Merge(*box2)
+	if !box1.Min.IsEqual(*core.NewPoint(-5, -7, -2)) || !box1.Max.IsEqual(*core.NewPoint(14, 4, 8)) {
+		t.Errorf("Expected box = (-5, -7, -2)..(14, 4, 8), but got %v..%v", box1.Min, box1.Max)
+	}
+}
+
+func TestTransformingBoundingBox(t *testing.T) {
+	// Scenario: Transforming a bounding box
+	// Given box ← bounding_box(min=point(-1, -1, -1) max=point(1, 1, 1))
+	box := shape.NewBoundingBox(*core.NewPoint(-1, -1, -1), *core.NewPoint(1, 1, 1))
+	// And matrix ← rotation_x(π / 4) * rotation_y(π / 4)
+	m := core.ChainTransforms([]*core.Matrix{core.RotateYM(math.Pi / 4), core.RotateXM(math.Pi / 4)})
+	// When box2 ← transform(box, matrix)
+	box2 := box.Transform(*m)
+	// Then box2.min = point(-1.4142, -1.7071, -1.7071)
+	// And box2.max = point(1.4142, 1.7071, 1.7071)
+	if !box2.Min.IsEqual(*core.NewPoint(-1.41421, -1.70711, -1.70711)) ||
+		!box2.Max.IsEqual(*core.NewPoint(1.41421, 1.70711, 1.70711)) {
+		t.Errorf("Unexpected transformed box %v..%v", box2.Min, box2.Max)
+	}
+
+	// infinite boxes stay infinite instead of turning into NaN
+	plane := shape.NewPlane()
+	plane.Transform = *core.RotateXM(math.Pi / 2)
+	if !shape.BoundsOf(plane).IsInfinite() {
+		t.Errorf("Expected bounds of a rotated plane to be infinite")
+	}
+}
+
+func TestShapeBounds(t *testing.T) {
+	cyl := shape.NewCylinder()
+	cyl.Minimum = -5
+	cyl.Maximum = 3
+	cone := shape.NewCone()
+	cone.Minimum = -5
+	cone.Maximum = 3
+	translated := shape.UnitSphere()
+	translated.Transform = *core.ChainTransforms([]*core.Matrix{core.ScaleM(0.5, 2, 4), core.TranslationM(1, -3, 5)})
+
+	examples := []struct {
+		name     string
+		bounds   shape.BoundingBox
+		min, max *core.Point
+	}{
+		{"sphere", shape.UnitSphere().Bounds(), core.NewPoint(-1, -1, -1), core.NewPoint(1, 1, 1)},
+		{"cube", shape.NewCube().Bounds(), core.NewPoint(-1, -1, -1), core.NewPoint(1, 1, 1)},
+		{"cylinder", cyl.Bounds(), core.NewPoint(-1, -5, -1), core.NewPoint(1, 3, 1)},
+		{"cone", cone.Bounds(), core.NewPoint(-5, -5, -5), core.NewPoint(5, 3, 5)},
+		{"triangle", shape.NewTriangle(*core.NewPoint(-3, 7, 2), *core.NewPoint(6, 2, -4), *core.NewPoint(2, -1, -1)).Bounds(),
+			core.NewPoint(-3, -1, -4), core.NewPoint(6, 7, 2)},
+		{"transformed sphere", shape.BoundsOf(translated), core.NewPoint(0.5, -5, 1), core.NewPoint(1.5, -1, 9)},
+	}
+
+	for _, e := range examples {
+		if !e.bounds.Min.IsEqual(*e.min) || !e.bounds.Max.IsEqual(*e.max) {
+			t.Errorf("%s: Expected bounds %v..%v, but got %v..%v", e.name, e.min, e.max, e.bounds.Min, e.bounds.Max)
+		}
+	}
+
+	// Scenario: A plane has a bounding box
+	planeBounds := shape.NewPlane().Bounds()
+	if !math.IsInf(planeBounds.Min.X, -1) || planeBounds.Min.Y != 0 || !math.IsInf(planeBounds.Max.Z, 1) {
+		t.Errorf("Unexpected plane bounds %v..%v", planeBounds.Min, planeBounds.Max)
+	}
+}
+
+func TestGroupBoundsContainChildren(t *testing.T) {
+	// Scenario: A group has a bounding box that contains its children
+	// Given s ← sphere()
+	// And set_transform(s, translation(2, 5, -3) * scaling(2, 2, 2))
+	s := shape.UnitSphere()
+	s.Transform = *core.ChainTransforms([]*core.Matrix{core.ScaleM(2, 2, 2), core.TranslationM(2, 5, -3)})
+	// And c ← cylinder()
+	// And c.minimum ← -2
+	// And c.maximum ← 2
+	// And set_transform(c, translation(-4, -1, 4) * scaling(0.5, 1, 0.5))
+	c := shape.NewCylinder()
+	c.Minimum = -2
+	c.Maximum = 2
+	c.Transform = *core.ChainTransforms([]*core.Matrix{core.ScaleM(0.5, 1, 0.5), core.TranslationM(-4, -1, 4)})
+	// And shape ← group()
+	// And add_child(shape, s)
+	// And add_child(shape, c)
+	g := shape.NewGroup()
+	g.AddChild(s, c)
+	// When box ← bounds_of(shape)
+	box := g.Bounds()
+	// Then box.min = point(-4.5, -3, -5)
+	// And box.max = point(4, 7, 4.5)
+	if !box.Min.IsEqual(*core.NewPoint(-4.5, -3, -5)) || !box.Max.IsEqual(*core.NewPoint(4, 7, 4.5)) {
+		t.Errorf("Expected group bounds (-4.5, -3, -5)..(4, 7, 4.5), but got %v..%v", box.Min, box.Max)
+	}
+}
+
+func TestIntersectingGroupMissesChildrenOutsideBounds(t *testing.T) {
+	// Scenario: Intersecting ray+group doesn't test children if box is missed
+	child := newTestShape()
+	g := shape.NewGroup()
+	g.AddChild(child)
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 1, 0),
+	}
+	r.Intersect(g)
+	// Then child.saved_ray is unset
+	if child.savedDirection != (core.Vector{}) {
+		t.Errorf("Expected child not to be intersected, but it saw direction %v", child.savedDirection)
+	}
+
+	// Scenario: Intersecting ray+group tests children if box is hit
+	r.Direction = *core.NewVector(0, 0, 1)
+	r.Intersect(g)
+	if child.savedDirection == (core.Vector{}) {
+		t.Errorf("Expected child to be intersected")
+	}
+}
+
+// grid of n x n small spheres on the xy plane, plus a floor
+func newGridWorld(n int) *scene.World {
+	w := scene.DefaultWorld()
+	w.Objects = []shape.Shape{shape.NewPlane()}
+	w.Objects[0].(*shape.Plane).Transform = *core.TranslationM(0, -1, 0)
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			s := shape.UnitSphere()
+			s.Transform = *core.ChainTransforms([]*core.Matrix{
+				core.ScaleM(0.4, 0.4, 0.4),
+				core.TranslationM(float64(i)-float64(n)/2, float64(j), 0),
+			})
+			w.Objects = append(w.Objects, s)
+		}
+	}
+	return w
+}
+
+func TestBuiltWorldGivesSameIntersections(t *testing.T) {
+	w := newGridWorld(6)
+	built := newGridWorld(6)
+	built.Objects = w.Objects
+	built.Build()
+
+	for x := -4.0; x <= 4; x += 0.5 {
+		for y := -1.0; y <= 6; y += 0.5 {
+			r := rayt.Ray{
+				Origin:    *core.NewPoint(0, 2, -10),
+				Direction: *core.NewPoint(x, y, 0).Subtract(*core.NewPoint(0, 2, -10)).Normalize(),
+			}
+			xs := scene.IntersectWorld(*w, r)
+			builtXs := scene.IntersectWorld(*built, r)
+			if len(xs) != len(builtXs) {
+				t.Fatalf("ray %v: Expected %d intersections, but got %d", r, len(xs), len(builtXs))
+			}
+			for i := range xs {
+				if xs[i].T != builtXs[i].T || xs[i].Object != builtXs[i].Object {
+					t.Errorf("ray %v: Expected xs[%d] = %v, but got %v", r, i, xs[i], builtXs[i])
+				}
+			}
+		}
+	}
+}
+
+func TestBuiltGroupGivesSameIntersections(t *testing.T) {
+	// a mesh of triangles forming a bumpy surface
+	mesh := shape.NewGroup()
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			x, z := float64(i)-4, float64(j)-4
+			h := math.Sin(x) * math.Cos(z)
+			mesh.AddChild(
+				shape.NewTriangle(*core.NewPoint(x, h, z), *core.NewPoint(x+1, h, z), *core.NewPoint(x, h, z+1)),
+				shape.NewTriangle(*core.NewPoint(x+1, h, z), *core.NewPoint(x+1, h, z+1), *core.NewPoint(x, h, z+1)),
+			)
+		}
+	}
+	mesh.Transform = *core.RotateXM(-math.Pi / 8)
+
+	origin := core.NewPoint(0.3, 10, 0.1)
+	expected := [][]rayt.Intersection{}
+	directions := []*core.Vector{}
+	for x := -4.0; x < 4; x += 0.7 {
+		for z := -4.0; z < 4; z += 0.7 {
+			d := core.NewPoint(x, 0, z).Subtract(*origin).Normalize()
+			directions = append(directions, d)
+			expected = append(expected, rayt.Ray{Origin: *origin, Direction: *d}.Intersect(mesh))
+		}
+	}
+
+	mesh.Build()
+	for i, d := range directions {
+		xs := rayt.Ray{Origin: *origin, Direction: *d}.Intersect(mesh)
+		if len(xs) != len(expected[i]) {
+			t.Fatalf("direction %v: Expected %d intersections, but got %d", d, len(expected[i]), len(xs))
+		}
+		for j := range xs {
+			if xs[j].T != expected[i][j].T || xs[j].Object != expected[i][j].Object {
+				t.Errorf("direction %v: Expected xs[%d] = %v, but got %v", d, j, expected[i][j], xs[j])
+			}
+		}
+	}
+}
+
+func TestRenderingBuiltWorldIsUnchanged(t *testing.T) {
+	w := newGridWorld(4)
+	c := scene.NewCamera(20, 15, math.Pi/3)
+	c.Transform = *scene.ViewTransform(*core.NewPoint(0, 2, -8), *core.NewPoint(0, 1.5, 0), *core.NewVector(0, 1, 0))
+
+	// render one pixel at a time through the unbuilt world, and compare it
+	// with Render (which builds the world first)
+	image := scene.Render(*c, *w)
+	for y := 0; y < c.Vsize; y++ {
+		for x := 0; x < c.Hsize; x++ {
+			expected := scene.ColorAt(*w, *scene.RayForPixel(*c, x, y), scene.MaxReflectionDepth)
+			if image.PixelAt(x, y) != expected {
+				t.Errorf("Expected pixel (%d, %d) = %v, but got %v", x, y, expected, image.PixelAt(x, y))
+			}
+		}
+	}
+}
+
+func TestAddingChildUpdatesGroupBounds(t *testing.T) {
+	outer := shape.NewGroup()
+	inner := shape.NewGroup()
+	outer.AddChild(inner)
+	s := shape.UnitSphere()
+	s.Transform = *core.TranslationM(5, 0, 0)
+	inner.AddChild(s)
+
+	// the bounds of the groups the child is added to are updated right away
+	expected := shape.NewBoundingBox(*core.NewPoint(4, -1, -1), *core.NewPoint(6, 1, 1))
+	for _, g := range []*shape.Group{inner, outer} {
+		if b := g.Bounds(); !b.Min.IsEqual(expected.Min) || !b.Max.IsEqual(expected.Max) {
+			t.Errorf("Expected group bounds = %v, but got %v", expected, b)
+		}
+	}
+}
+
+func TestAddingManyChildrenToGroup(t *testing.T) {
+	// a mesh built one triangle at a time, inside another group. Adding a
+	// child only grows the bounds of the groups, so this stays fast even with
+	// thousands of triangles
+	outer := shape.NewGroup()
+	outer.Transform = *core.TranslationM(0, 1, 0)
+	mesh := shape.NewGroup()
+	mesh.Transform = *core.ScaleM(2, 2, 2)
+	outer.AddChild(mesh, shape.NewGroup())
+	for i := 0; i < 5000; i++ {
+		x, z := float64(i%100), float64(i/100)
+		mesh.AddChild(shape.NewTriangle(*core.NewPoint(x, 0, z), *core.NewPoint(x+1, 0.5, z), *core.NewPoint(x, 0, z+1)))
+	}
+
+	meshBounds, outerBounds := mesh.Bounds(), outer.Bounds()
+	// the same bounds as computed from scratch
+	outer.Build()
+	for _, test := range []struct {
+		name        string
+		got, expect shape.BoundingBox
+	}{
+		{"mesh", meshBounds, mesh.Bounds()},
+		{"outer", outerBounds, outer.Bounds()},
+	} {
+		if !test.got.Min.IsEqual(test.expect.Min) || !test.got.Max.IsEqual(test.expect.Max) {
+			t.Errorf("Expected the %s bounds = %v, but got %v", test.name, test.expect, test.got)
+		}
+	}
+	// in the space of the outer group, where the mesh is scaled
+	expected := shape.NewBoundingBox(*core.NewPoint(0, 0, 0), *core.NewPoint(200, 1, 100))
+	if !outerBounds.Min.IsEqual(expected.Min) || !outerBounds.Max.IsEqual(expected.Max) {
+		t.Errorf("Expected the outer bounds = %v, but got %v", expected, outerBounds)
+	}
+}
+
+func TestRenderingAfterAddingToBuiltWorld(t *testing.T) {
+	// a group that starts empty, in a world that is built before a sphere is
+	// added to the group
+	g := shape.NewGroup()
+	w := scene.DefaultWorld()
+	w.Objects = []shape.Shape{g}
+	w.Build()
+	g.AddChild(shape.UnitSphere())
+
+	c := scene.NewCamera(11, 11, math.Pi/2)
+	c.Transform = *scene.ViewTransform(*core.NewPoint(0, 0, -5), *core.NewPoint(0, 0, 0), *core.NewVector(0, 1, 0))
+
+	// the sphere shows up in the middle of the image
+	for _, image := range []*rendering.Canvas{
+		scene.Render(*c, *w),
+		scene.RenderParallel(*c, *w, scene.RenderOptions{Workers: 4, TileSize: 2}),
+	} {
+		if image.PixelAt(5, 5) == *color.Black {
+			t.Errorf("Expected the sphere added after building the world to be rendered")
+		}
+	}
+}
+
+/* ------------- Constructive solid geometry --------------- */
+func TestCSGCreatedWithOperationAndTwoShapes(t *testing.T) {
+	// Scenario: CSG is created with an operation and two shapes
+	s1 := shape.UnitSphere()
+	s2 := shape.NewCube()
+	// When c ← csg("union", s1, s2)
+	c := shape.NewCSG(shape.CSGUnion, s1, s2)
+	// Then c.operation = "union"
+	// And c.left = s1
+	// And c.right = s2
+	if c.Operation != shape.CSGUnion || c.Left != s1 || c.Right != s2 {
+		t.Errorf("Unexpected csg %+v", c)
+	}
+	// And s1.parent = c
+	// And s2.parent = c
+	if s1.GetParent() != c || s2.GetParent() != c {
+		t.Errorf("Expected the parent of both shapes to be the csg")
+	}
+}
+
+func TestCSGIntersectionAllowed(t *testing.T) {
+	// Scenario Outline: Evaluating the rule for a CSG operation
+	examples := []struct {
+		op                      shape.CSGOperation
+		lhit, inl, inr, allowed bool
+	}{
+		{shape.CSGUnion, true, true, true, false},
+		{shape.CSGUnion, true, true, false, true},
+		{shape.CSGUnion, true, false, true, false},
+		{shape.CSGUnion, true, false, false, true},
+		{shape.CSGUnion, false, true, true, false},
+		{shape.CSGUnion, false, true, false, false},
+		{shape.CSGUnion, false, false, true, true},
+		{shape.CSGUnion, false, false, false, true},
+		{shape.CSGIntersection, true, true, true, true},
+		{shape.CSGIntersection, true, true, false, false},
+		{shape.CSGIntersection, true, false, true, true},
+		{shape.CSGIntersection, true, false, false, false},
+		{shape.CSGIntersection, false, true, true, true},
+		{shape.CSGIntersection, false, true, false, true},
+		{shape.CSGIntersection, false, false, true, false},
+		{shape.CSGIntersection, false, false, false, false},
+		{shape.CSGDifference, true, true, true, false},
+		{shape.CSGDifference, true, true, false, true},
+		{shape.CSGDifference, true, false, true, false},
+		{shape.CSGDifference, true, false, false, true},
+		{shape.CSGDifference, false, true, true, true},
+		{shape.CSGDifference, false, true, false, true},
+		{shape.CSGDifference, false, false, true, false},
+		{shape.CSGDifference, false, false, false, false},
+	}
+
+	for _, e := range examples {
+		// When result ← intersection_allowed("<op>", <lhit>, <inl>, <inr>)
+		result := shape.IntersectionAllowed(e.op, e.lhit, e.inl, e.inr)
+		// Then result = <result>
+		if result != e.allowed {
+			t.Errorf("intersection_allowed(%v, %v, %v, %v): Expected %v, but got %v",
+				e.op, e.lhit, e.inl, e.inr, e.allowed, result)
+		}
+	}
+}
+
+func TestCSGFilteringListOfIntersections(t *testing.T) {
+	// Scenario Outline: Filtering a list of intersections
+	examples := []struct {
+		op     shape.CSGOperation
+		x0, x1 int
+	}{
+		{shape.CSGUnion, 0, 3},
+		{shape.CSGIntersection, 1, 2},
+		{shape.CSGDifference, 0, 1},
+	}
+
+	for _, e := range examples {
+		// Given s1 ← sphere()
+		// And s2 ← cube()
+		s1 := shape.UnitSphere()
+		s2 := shape.NewCube()
+		// And c ← csg("<operation>", s1, s2)
+		c := shape.NewCSG(e.op, s1, s2)
+		// And xs ← intersections(1:s1, 2:s2, 3:s1, 4:s2)
+		xs := []rayt.Intersection{
+			rayt.NewIntersection(1, s1),
+			rayt.NewIntersection(2, s2),
+			rayt.NewIntersection(3, s1),
+			rayt.NewIntersection(4, s2),
+		}
+		// When result ← filter_intersections(c, xs)
+		result := c.FilterIntersections(xs)
+		// Then result.count = 2
+		if len(result) != 2 {
+			t.Errorf("%v: Expected result.count = 2, but got %d", e.op, len(result))
+			continue
+		}
+		// And result[0] = xs[<x0>]
+		// And result[1] = xs[<x1>]
+		if result[0] != xs[e.x0] || result[1] != xs[e.x1] {
+			t.Errorf("%v: Expected result = (xs[%d], xs[%d]), but got %v", e.op, e.x0, e.x1, result)
+		}
+	}
+}
+
+func TestRayMissesCSGObject(t *testing.T) {
+	// Scenario: A ray misses a CSG object
+	c := shape.NewCSG(shape.CSGUnion, shape.UnitSphere(), shape.NewCube())
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 2, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	xs := r.Intersect(c)
+	// Then xs is empty
+	if len(xs) != 0 {
+		t.Errorf("Expected xs.count = 0, but got %d", len(xs))
+	}
+}
+
+func TestRayHitsCSGObject(t *testing.T) {
+	// Scenario: A ray hits a CSG object
+	// Given s1 ← sphere()
+	// And s2 ← sphere()
+	// And set_transform(s2, translation(0, 0, 0.5))
+	s1 := shape.UnitSphere()
+	s2 := shape.UnitSphere()
+	s2.Transform = *core.TranslationM(0, 0, 0.5)
+	// And c ← csg("union", s1, s2)
+	c := shape.NewCSG(shape.CSGUnion, s1, s2)
+	// And r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	// When xs ← local_intersect(c, r)
+	xs := r.Intersect(c)
+	// Then xs.count = 2
+	if len(xs) != 2 {
+		t.Fatalf("Expected xs.count = 2, but got %d", len(xs))
+	}
+	// And xs[0].t = 4
+	// And xs[0].object = s1
+	if !core.IsFloatEqual(xs[0].T, 4) || xs[0].Object != s1 {
+		t.Errorf("Expected xs[0] = (4, s1), but got %v", xs[0])
+	}
+	// And xs[1].t = 6.5
+	// And xs[1].object = s2
+	if !core.IsFloatEqual(xs[1].T, 6.5) || xs[1].Object != s2 {
+		t.Errorf("Expected xs[1] = (6.5, s2), but got %v", xs[1])
+	}
+}
+
+func TestCSGDrilledCube(t *testing.T) {
+	// a cube with a hole drilled through it along the y axis, nested in a
+	// group: rays down the hole pass through, rays beside it hit the top
+	hole := shape.NewCylinder()
+	hole.Minimum = -2
+	hole.Maximum = 2
+	hole.Closed = true
+	hole.Transform = *core.ScaleM(0.5, 1, 0.5)
+	drilled := shape.NewCSG(shape.CSGDifference, shape.NewCube(), hole)
+	g := shape.NewGroup()
+	g.AddChild(drilled)
+	g.Build()
+
+	down := core.NewVector(0, -1, 0)
+	xs := rayt.Ray{Origin: *core.NewPoint(0, 5, 0), Direction: *down}.Intersect(g)
+	if len(xs) != 0 {
+		t.Errorf("Expected the ray down the hole to pass through, but got %d intersections", len(xs))
+	}
+
+	xs = rayt.Ray{Origin: *core.NewPoint(0.75, 5, 0), Direction: *down}.Intersect(g)
+	if len(xs) != 2 || !core.IsFloatEqual(xs[0].T, 4) {
+		t.Fatalf("Expected the ray beside the hole to hit the top of the cube at t = 4, but got %v", xs)
+	}
+
+	// the normal inside the hole comes from the cylinder, and is flipped to
+	// face the ray by prepare computations
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, 0), Direction: *core.NewVector(1, 0, 0)}
+	xs = r.Intersect(g)
+	hit := r.Hit(xs)
+	if hit == nil || hit.Object != hole {
+		t.Fatalf("Expected the ray from the middle of the hole to hit its wall, but got %v", hit)
+	}
+	comps := scene.PrepareComputations(*hit, r, xs)
+	if !comps.NormalV.IsEqual(*core.NewVector(-1, 0, 0)) {
+		t.Errorf("Expected normal = (-1, 0, 0), but got %v", comps.NormalV)
+	}
+}
+
+/* ------------- Parallel rendering --------------- */
+func TestRenderParallelMatchesRender(t *testing.T) {
+	w := newGridWorld(3)
+	// a group and a csg, so that the hierarchy built before the workers
+	// start is exercised as well
+	g := shape.NewGroup()
+	g.Transform = *core.TranslationM(0, 0, -2)
+	g.AddChild(shape.NewCSG(shape.CSGDifference, shape.NewCube(), shape.UnitSphere()))
+	w.Objects = append(w.Objects, g)
+
+	// 23x17 is not a multiple of any of the tile sizes below
+	c := scene.NewCamera(23, 17, math.Pi/3)
+	c.Transform = *scene.ViewTransform(*core.NewPoint(1, 3, -8), *core.NewPoint(0, 1, 0), *core.NewVector(0, 1, 0))
+
+	expected := scene.Render(*c, *w)
+
+	for _, opts := range []scene.RenderOptions{
+		{},
+		{Workers: 1, TileSize: 1},
+		{Workers: 3, TileSize: 5},
+		{Workers: 8, TileSize: 64},
+	} {
+		image := scene.RenderParallel(*c, *w, opts)
+		for y := 0; y < c.Vsize; y++ {
+			for x := 0; x < c.Hsize; x++ {
+				// bit-identical, not just within EPSILON
+				if image.PixelAt(x, y) != expected.PixelAt(x, y) {
+					t.Fatalf("%+v: Expected pixel (%d, %d) = %v, but got %v",
+						opts, x, y, expected.PixelAt(x, y), image.PixelAt(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestRenderContextReportsProgress(t *testing.T) {
+	w := scene.DefaultWorld()
+	c := scene.NewCamera(20, 10, math.Pi/2)
+	c.Transform = *scene.ViewTransform(*core.NewPoint(0, 0, -5), *core.NewPoint(0, 0, 0), *core.NewVector(0, 1, 0))
+
+	reports := []scene.RenderProgress{}
+	opts := scene.RenderOptions{
+		Workers:  4,
+		TileSize: 4,
+		Progress: func(p scene.RenderProgress) { reports = append(reports, p) },
+	}
+	image, err := scene.RenderContext(context.Background(), *c, *w, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	// 20x10 in 4x4 tiles is 5 columns by 3 rows of tiles
+	if len(reports) != 15 {
+		t.Fatalf("Expected 15 progress reports, but got %d", len(reports))
+	}
+	for i, p := range reports {
+		if p.TilesDone != i+1 || p.TilesTotal != 15 {
+			t.Errorf("Expected report %d to be %d/15 tiles, but got %d/%d", i, i+1, p.TilesDone, p.TilesTotal)
+		}
+		if i > 0 && p.Elapsed < reports[i-1].Elapsed {
+			t.Errorf("Expected elapsed time to never decrease, but got %v after %v", p.Elapsed, reports[i-1].Elapsed)
+		}
+	}
+	if last := reports[len(reports)-1]; last.ETA != 0 {
+		t.Errorf("Expected ETA = 0 once every tile is done, but got %v", last.ETA)
+	}
+
+	expected := scene.Render(*c, *w)
+	if image.PixelAt(10, 5) != expected.PixelAt(10, 5) {
+		t.Errorf("Expected pixel (10, 5) = %v, but got %v", expected.PixelAt(10, 5), image.PixelAt(10, 5))
+	}
+}
+
+func TestRenderContextStopsWhenCancelled(t *testing.T) {
+	w := scene.DefaultWorld()
+	c := scene.NewCamera(40, 40, math.Pi/2)
+
+	// cancelled before the render starts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := scene.RenderContext(ctx, *c, *w, scene.RenderOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+	}
+
+	// cancelled in the middle of the render: no more tiles are reported
+	// once the context is cancelled
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	tilesDone := 0
+	opts := scene.RenderOptions{
+		Workers:  2,
+		TileSize: 2,
+		Progress: func(p scene.RenderProgress) {
+			tilesDone = p.TilesDone
+			if p.TilesDone == 3 {
+				cancel()
+			}
+		},
+	}
+	_, err = scene.RenderContext(ctx, *c, *w, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+	}
+	if tilesDone != 3 {
+		t.Errorf("Expected rendering to stop after 3 of 400 tiles, but %d were reported", tilesDone)
+	}
+}
+
+/* ------------- Anti-aliasing --------------- */
+
+// a flat white sphere (ambient only) in front of the camera, so that every
+// pixel is either 0 (background) or 1 (sphere) without anti-aliasing
+func newFlatSphereScene() (*scene.Camera, *scene.World) {
+	s := shape.UnitSphere()
+	s.Material.Ambient = 1
+	s.Material.Diffuse = 0
+	s.Material.Specular = 0
+	w := scene.DefaultWorld()
+	w.Objects = []shape.Shape{s}
+
+	c := scene.NewCamera(15, 15, math.Pi/3)
+	c.Transform = *scene.ViewTransform(*core.NewPoint(0, 0, -4), *core.NewPoint(0, 0, 0), *core.NewVector(0, 1, 0))
+	return c, w
+}
+
+func TestRayForPixelOffsetAtCenterIsRayForPixel(t *testing.T) {
+	c := scene.NewCamera(201, 101, math.Pi/2)
+	r := scene.RayForPixel(*c, 0, 0)
+	ro := scene.RayForPixelOffset(*c, 0, 0, 0.5, 0.5)
+	if *r != *ro {
+		t.Errorf("Expected ray through the center = %v, but got %v", r, ro)
+	}
+
+	// the offset moves the ray within the pixel: the top left corner of the
+	// top left pixel is the edge of the field of view
+	ro = scene.RayForPixelOffset(*c, 0, 0, 0, 0)
+	halfWidth := c.HalfWidth
+	halfHeight := halfWidth * 101 / 201
+	expected := core.NewVector(halfWidth, halfHeight, -1).Normalize()
+	if !ro.Direction.IsEqual(*expected) {
+		t.Errorf("Expected r.direction = %v, but got %v", expected, ro.Direction)
+	}
+}
+
+func TestDefaultSamplingIsSingleCenterRay(t *testing.T) {
+	c, w := newFlatSphereScene()
+	for y := 0; y < c.Vsize; y++ {
+		for x := 0; x < c.Hsize; x++ {
+			expected := scene.ColorAt(*w, *scene.RayForPixel(*c, x, y), scene.MaxReflectionDepth)
+			if got := scene.ColorForPixel(*c, *w, x, y); got != expected {
+				t.Fatalf("Expected pixel (%d, %d) = %v, but got %v", x, y, expected, got)
+			}
+		}
+	}
+}
+
+func TestSupersamplingSmoothsEdges(t *testing.T) {
+	for _, pattern := range []scene.SamplePattern{scene.RegularSampling, scene.JitteredSampling, scene.RandomSampling} {
+		for _, filter := range []scene.Filter{scene.BoxFilter, scene.TentFilter, scene.GaussianFilter, scene.MitchellFilter} {
+			c, w := newFlatSphereScene()
+			c.Sampling = scene.Sampling{Samples: 4, Pattern: pattern, Filter: filter, Seed: 7}
+			image := scene.Render(*c, *w)
+
+			// the center is fully covered by the sphere and the corner by the
+			// background, whatever the filter
+			if center := image.PixelAt(7, 7); !center.IsEqual(*color.NewColor(1, 1, 1)) {
+				t.Errorf("pattern %v, filter %v: Expected center = white, but got %v", pattern, filter, center)
+			}
+			if corner := image.PixelAt(0, 0); !corner.IsEqual(*color.Black) {
+				t.Errorf("pattern %v, filter %v: Expected corner = black, but got %v", pattern, filter, corner)
+			}
+
+			// pixels on the silhouette are neither background nor sphere, but
+			// stay between the two
+			partial := 0
+			for y := 0; y < c.Vsize; y++ {
+				for x := 0; x < c.Hsize; x++ {
+					p := image.PixelAt(x, y)
+					if p.R < -core.EPSILON || p.R > 1+core.EPSILON {
+						t.Errorf("pattern %v, filter %v: Expected pixel (%d, %d) within [0, 1], but got %v", pattern, filter, x, y, p)
+					}
+					if math.Abs(p.R) > 0.01 && math.Abs(p.R-1) > 0.01 {
+						partial++
+					}
+				}
+			}
+			if partial == 0 {
+				t.Errorf("pattern %v, filter %v: Expected partially covered pixels on the edge of the sphere", pattern, filter)
+			}
+		}
+	}
+}
+
+func TestMitchellFilterStaysWithinSceneColors(t *testing.T) {
+	// with random samples a few of them can end up close together near the
+	// edge of the pixel, which mustn't let the mitchell filter push the color
+	// outside of [0, 1]
+	for _, pattern := range []scene.SamplePattern{scene.JitteredSampling, scene.RandomSampling} {
+		for seed := uint64(1); seed <= 5; seed++ {
+			c, w := newFlatSphereScene()
+			c.Sampling = scene.Sampling{Samples: 3, Pattern: pattern, Filter: scene.MitchellFilter, Seed: seed}
+			image := scene.Render(*c, *w)
+			for y := 0; y < c.Vsize; y++ {
+				for x := 0; x < c.Hsize; x++ {
+					if p := image.PixelAt(x, y); p.R < -core.EPSILON || p.R > 1+core.EPSILON {
+						t.Fatalf("pattern %v, seed %d: Expected pixel (%d, %d) within [0, 1], but got %v", pattern, seed, x, y, p)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSeededSamplingIsReproducible(t *testing.T) {
+	c, w := newFlatSphereScene()
+	c.Sampling = scene.Sampling{Samples: 3, Pattern: scene.RandomSampling, Filter: scene.GaussianFilter, Seed: 42}
+
+	// same seed gives the same image, even when rendered in parallel
+	expected := scene.Render(*c, *w)
+	image := scene.RenderParallel(*c, *w, scene.RenderOptions{Workers: 4, TileSize: 3})
+	differentSeed := *c
+	differentSeed.Sampling.Seed = 43
+	other := scene.Render(differentSeed, *w)
+
+	same, differs := true, false
+	for y := 0; y < c.Vsize; y++ {
+		for x := 0; x < c.Hsize; x++ {
+			same = same && image.PixelAt(x, y) == expected.PixelAt(x, y)
+			differs = differs || other.PixelAt(x, y) != expected.PixelAt(x, y)
+		}
+	}
+	if !same {
+		t.Errorf("Expected the same seed to give the same image")
+	}
+	if !differs {
+		t.Errorf("Expected a different seed to give a different image")
+	}
+}
+
+/* ------------- Reflection --------------- */
+
+// default world with a reflective plane below the spheres
+func newWorldWithReflectivePlane() (*scene.World, *shape.Plane) {
+	w := scene.DefaultWorld()
+	p := shape.NewPlane()
+	p.Material.Reflective = 0.5
+	p.Transform = *core.TranslationM(0, -1, 0)
+	w.Objects = append(w.Objects, p)
+	return w, p
+}
+
+func TestDefaultMaterialIsNotReflective(t *testing.T) {
+	// Scenario: Reflectivity for the default material
+	// Given m ← material()
+	m := material.DefaultMaterial()
+
+	// Then m.reflective = 0.0
+	if m.Reflective != 0 {
+		t.Errorf("Expected m.reflective = 0, but got %v", m.Reflective)
+	}
+}
+
+func TestPrepareComputations_ReflectionVector(t *testing.T) {
+	// Scenario: Precomputing the reflection vector
+	// Given shape ← plane()
+	p := shape.NewPlane()
+
+	// And r ← ray(point(0, 1, -1), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 1, -1),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And i ← intersection(√2, shape)
+	i := rayt.NewIntersection(math.Sqrt2, p)
+
+	// When comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	// Then comps.reflectv = vector(0, √2/2, √2/2)
+	expected := core.NewVector(0, math.Sqrt2/2, math.Sqrt2/2)
+	if !comps.ReflectV.IsEqual(*expected) {
+		t.Errorf("Expected comps.reflectv = %v, but got %v", expected, comps.ReflectV)
+	}
+}
+
+func TestReflectedColor_NonReflectiveMaterial(t *testing.T) {
+	// Scenario: The reflected color for a nonreflective material
+	// Given w ← default_world()
+	w := scene.DefaultWorld()
+
+	// And r ← ray(point(0, 0, 0), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, 0),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And shape ← the second object in w
+	// And shape.material.ambient ← 1
+	s := w.Objects[1]
+	s.GetMaterial().Ambient = 1
+
+	// And i ← intersection(1, shape)
+	i := rayt.NewIntersection(1, s)
+
+	// When comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	// And color ← reflected_color(w, comps)
+	c := scene.ReflectedColor(*w, *comps, scene.MaxReflectionDepth)
+
+	// Then color = color(0, 0, 0)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected reflected color = black, but got %v", c)
+	}
+}
+
+func TestReflectedColor_ReflectiveMaterial(t *testing.T) {
+	// Scenario: The reflected color for a reflective material
+	// Given w ← default_world()
+	// And shape ← plane() with:
+	//   | material.reflective | 0.5                   |
+	//   | transform           | translation(0, -1, 0) |
+	// And shape is added to w
+	w, p := newWorldWithReflectivePlane()
+
+	// And r ← ray(point(0, 0, -3), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -3),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And i ← intersection(√2, shape)
+	i := rayt.NewIntersection(math.Sqrt2, p)
+
+	// When comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	// And color ← reflected_color(w, comps)
+	c := scene.ReflectedColor(*w, *comps, scene.MaxReflectionDepth)
+
+	// Then color = color(0.19032, 0.2379, 0.14274)
+	// (0.19033, 0.23791, 0.14274 with the EPSILON used for the over point)
+	expected := color.NewColor(0.19033, 0.23791, 0.14274)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected reflected color = %v, but got %v", expected, c)
+	}
+}
+
+func TestShadeHit_ReflectiveMaterial(t *testing.T) {
+	// Scenario: shade_hit() with a reflective material
+	// Given w ← default_world()
+	// And shape ← plane() with:
+	//   | material.reflective | 0.5                   |
+	//   | transform           | translation(0, -1, 0) |
+	// And shape is added to w
+	w, p := newWorldWithReflectivePlane()
+
+	// And r ← ray(point(0, 0, -3), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -3),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And i ← intersection(√2, shape)
+	i := rayt.NewIntersection(math.Sqrt2, p)
+
+	// When comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	// And color ← shade_hit(w, comps)
+	c := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+
+	// Then color = color(0.87677, 0.92436, 0.82918)
+	// (0.87675, 0.92434, 0.82917 with the EPSILON used for the over point)
+	expected := color.NewColor(0.87675, 0.92434, 0.82917)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected shade_hit result = %v, but got %v", expected, c)
+	}
+}
+
+func TestColorAt_MutuallyReflectiveSurfaces(t *testing.T) {
+	// Scenario: color_at() with mutually reflective surfaces
+	// Given w ← world()
+	// And w.light ← point_light(point(0, 0, 0), color(1, 1, 1))
+	w := &scene.World{Lights: []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 0))}}
+
+	// And lower ← plane() with:
+	//   | material.reflective | 1                     |
+	//   | transform           | translation(0, -1, 0) |
+	lower := shape.NewPlane()
+	lower.Material.Reflective = 1
+	lower.Transform = *core.TranslationM(0, -1, 0)
+
+	// And upper ← plane() with:
+	//   | material.reflective | 1                    |
+	//   | transform           | translation(0, 1, 0) |
+	upper := shape.NewPlane()
+	upper.Material.Reflective = 1
+	upper.Transform = *core.TranslationM(0, 1, 0)
+	w.Objects = []shape.Shape{lower, upper}
+
+	// And r ← ray(point(0, 0, 0), vector(0, 1, 0))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, 0),
+		Direction: *core.NewVector(0, 1, 0),
+	}
+
+	// Then color_at(w, r) should terminate successfully
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
+
+	// every bounce adds the (fully lit) surface color of the plane, so the
+	// ray must have bounced more than once, but not forever
+	if c.R <= 1 || c.R > float64(scene.MaxReflectionDepth+1)*2 {
+		t.Errorf("Expected the ray to bounce between the planes a few times, but got %v", c)
+	}
+}
+
+func TestReflectedColor_MaximumRecursiveDepth(t *testing.T) {
+	// Scenario: The reflected color at the maximum recursive depth
+	// Given w ← default_world()
+	// And shape ← plane() with:
+	//   | material.reflective | 0.5                   |
+	//   | transform           | translation(0, -1, 0) |
+	// And shape is added to w
+	w, p := newWorldWithReflectivePlane()
+
+	// And r ← ray(point(0, 0, -3), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -3),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And i ← intersection(√2, shape)
+	i := rayt.NewIntersection(math.Sqrt2, p)
+
+	// When comps ← prepare_computations(i, r)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	// And color ← reflected_color(w, comps, 0)
+	c := scene.ReflectedColor(*w, *comps, 0)
+
+	// Then color = color(0, 0, 0)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected reflected color = black, but got %v", c)
+	}
+}
+
+/* ------------- Refraction --------------- */
+
+func newGlassSphere() *shape.Sphere {
+	s := shape.UnitSphere()
+	s.Material.Transparency = 1.0
+	s.Material.RefractiveIndex = 1.5
+	return s
+}
+
+func TestDefaultMaterialIsOpaque(t *testing.T) {
+	// Scenario: Transparency and Refractive Index for the default material
+	// Given m ← material()
+	m := material.DefaultMaterial()
+
+	// Then m.transparency = 0.0
+	// And m.refractive_index = 1.0
+	if m.Transparency != 0 || m.RefractiveIndex != 1 {
+		t.Errorf("Expected transparency = 0 and refractive index = 1, but got %v and %v", m.Transparency, m.RefractiveIndex)
+	}
+}
+
+func TestGlassSphere(t *testing.T) {
+	// Scenario: A helper for producing a sphere with a glassy material
+	// Given s ← glass_sphere()
+	s := newGlassSphere()
+
+	// Then s.transform = identity_matrix
+	// And s.material.transparency = 1.0
+	// And s.material.refractive_index = 1.5
+	if !s.Transform.IsEqual(*core.IdentityMatrix()) {
+		t.Errorf("Expected s.transform = identity, but got %v", s.Transform)
+	}
+	if s.Material.Transparency != 1 || s.Material.RefractiveIndex != 1.5 {
+		t.Errorf("Expected a glassy material, but got %v", s.Material)
+	}
+}
+
+func TestPrepareComputations_N1N2(t *testing.T) {
+	// Scenario Outline: Finding n1 and n2 at various intersections
+	// Given A ← glass_sphere() with:
+	//   | transform                 | scaling(2, 2, 2) |
+	//   | material.refractive_index | 1.5              |
+	a := newGlassSphere()
+	a.Transform = *core.ScaleM(2, 2, 2)
+	a.Material.RefractiveIndex = 1.5
+
+	// And B ← glass_sphere() with:
+	//   | transform                 | translation(0, 0, -0.25) |
+	//   | material.refractive_index | 2.0                      |
+	b := newGlassSphere()
+	b.Transform = *core.TranslationM(0, 0, -0.25)
+	b.Material.RefractiveIndex = 2.0
+
+	// And C ← glass_sphere() with:
+	//   | transform                 | translation(0, 0, 0.25) |
+	//   | material.refractive_index | 2.5                     |
+	c := newGlassSphere()
+	c.Transform = *core.TranslationM(0, 0, 0.25)
+	c.Material.RefractiveIndex = 2.5
+
+	// And r ← ray(point(0, 0, -4), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -4),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And xs ← intersections(2:A, 2.75:B, 3.25:C, 4.75:B, 5.25:C, 6:A)
+	xs := []rayt.Intersection{
+		rayt.NewIntersection(2, a),
+		rayt.NewIntersection(2.75, b),
+		rayt.NewIntersection(3.25, c),
+		rayt.NewIntersection(4.75, b),
+		rayt.NewIntersection(5.25, c),
+		rayt.NewIntersection(6, a),
+	}
+
+	// When comps ← prepare_computations(xs[<index>], r, xs)
+	// Then comps.n1 = <n1>
+	// And comps.n2 = <n2>
+	tests := []struct {
+		n1, n2 float64
+	}{
+		{1.0, 1.5},
+		{1.5, 2.0},
+		{2.0, 2.5},
+		{2.5, 2.5},
+		{2.5, 1.5},
+		{1.5, 1.0},
+	}
+	for index, test := range tests {
+		comps := scene.PrepareComputations(xs[index], r, xs)
+		if comps.N1 != test.n1 || comps.N2 != test.n2 {
+			t.Errorf("xs[%d]: Expected n1 = %v, n2 = %v, but got %v, %v", index, test.n1, test.n2, comps.N1, comps.N2)
+		}
+	}
+}
+
+func TestPrepareComputations_UnderPoint(t *testing.T) {
+	// Scenario: The under point is offset below the surface
+	// Given r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And shape ← glass_sphere() with:
+	//   | transform | translation(0, 0, 1) |
+	s := newGlassSphere()
+	s.Transform = *core.TranslationM(0, 0, 1)
+
+	// And i ← intersection(5, shape)
+	// And xs ← intersections(i)
+	i := rayt.NewIntersection(5, s)
+	xs := []rayt.Intersection{i}
+
+	// When comps ← prepare_computations(i, r, xs)
+	comps := scene.PrepareComputations(i, r, xs)
+
+	// Then comps.under_point.z > EPSILON/2
+	// And comps.point.z < comps.under_point.z
+	if comps.UnderPoint.Z <= core.EPSILON/2 {
+		t.Errorf("Expected under_point.z > EPSILON/2, but got %v", comps.UnderPoint.Z)
+	}
+	if comps.Point.Z >= comps.UnderPoint.Z {
+		t.Errorf("Expected point.z < under_point.z, but got %v and %v", comps.Point.Z, comps.UnderPoint.Z)
+	}
+}
+
+func TestRefractedColor_OpaqueSurface(t *testing.T) {
+	// Scenario: The refracted color with an opaque surface
+	// Given w ← default_world()
+	// And shape ← the first object in w
+	w := scene.DefaultWorld()
+	s := w.Objects[0]
+
+	// And r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And xs ← intersections(4:shape, 6:shape)
+	xs := []rayt.Intersection{rayt.NewIntersection(4, s), rayt.NewIntersection(6, s)}
+
+	// When comps ← prepare_computations(xs[0], r, xs)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+
+	// And c ← refracted_color(w, comps, 5)
+	c := scene.RefractedColor(*w, *comps, 5)
+
+	// Then c = color(0, 0, 0)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected refracted color = black, but got %v", c)
+	}
+}
+
+func TestRefractedColor_MaximumRecursiveDepth(t *testing.T) {
+	// Scenario: The refracted color at the maximum recursive depth
+	// Given w ← default_world()
+	// And shape ← the first object in w
+	// And shape has:
+	//   | material.transparency     | 1.0 |
+	//   | material.refractive_index | 1.5 |
+	w := scene.DefaultWorld()
+	s := w.Objects[0]
+	s.GetMaterial().Transparency = 1.0
+	s.GetMaterial().RefractiveIndex = 1.5
+
+	// And r ← ray(point(0, 0, -5), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And xs ← intersections(4:shape, 6:shape)
+	xs := []rayt.Intersection{rayt.NewIntersection(4, s), rayt.NewIntersection(6, s)}
+
+	// When comps ← prepare_computations(xs[0], r, xs)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+
+	// And c ← refracted_color(w, comps, 0)
+	c := scene.RefractedColor(*w, *comps, 0)
+
+	// Then c = color(0, 0, 0)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected refracted color = black, but got %v", c)
+	}
+}
+
+func TestRefractedColor_TotalInternalReflection(t *testing.T) {
+	// Scenario: The refracted color under total internal reflection
+	// Given w ← default_world()
+	// And shape ← the first object in w
+	// And shape has:
+	//   | material.transparency     | 1.0 |
+	//   | material.refractive_index | 1.5 |
+	w := scene.DefaultWorld()
+	s := w.Objects[0]
+	s.GetMaterial().Transparency = 1.0
+	s.GetMaterial().RefractiveIndex = 1.5
+
+	// And r ← ray(point(0, 0, √2/2), vector(0, 1, 0))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, math.Sqrt2/2),
+		Direction: *core.NewVector(0, 1, 0),
+	}
+
+	// And xs ← intersections(-√2/2:shape, √2/2:shape)
+	xs := []rayt.Intersection{
+		rayt.NewIntersection(-math.Sqrt2/2, s),
+		rayt.NewIntersection(math.Sqrt2/2, s),
+	}
+
+	// When comps ← prepare_computations(xs[1], r, xs)
+	// NOTE: this time you're inside the sphere, so you need
+	// to look at the second intersection, xs[1], not xs[0]
+	comps := scene.PrepareComputations(xs[1], r, xs)
+
+	// And c ← refracted_color(w, comps, 5)
+	c := scene.RefractedColor(*w, *comps, 5)
+
+	// Then c = color(0, 0, 0)
+	if !c.IsEqual(*color.Black) {
+		t.Errorf("Expected refracted color = black, but got %v", c)
+	}
+}
+
+func TestRefractedColor_RefractedRay(t *testing.T) {
+	// Looking through a glass pane at a wall behind it: the wall (lit only by
+	// its ambient color) is seen through the pane, dimmed by its transparency
+	w := &scene.World{Lights: []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, -10))}}
+
+	pane := shape.NewCube()
+	pane.Transform = *core.ScaleM(1, 1, 0.1)
+	pane.Material.Transparency = 0.5
+	pane.Material.RefractiveIndex = material.Glass
+	// the pane itself is not lit, so only the wall seen through it counts
+	pane.Material.Ambient = 0
+	pane.Material.Diffuse = 0
+	pane.Material.Specular = 0
+
+	wall := shape.NewPlane()
+	wall.Transform = *core.ChainTransforms([]*core.Matrix{core.RotateXM(math.Pi / 2), core.TranslationM(0, 0, 5)})
+	wall.Material.Color = *color.NewColor(0, 1, 0)
+	wall.Material.Ambient = 1
+	wall.Material.Diffuse = 0
+	wall.Material.Specular = 0
+	w.Objects = []shape.Shape{pane, wall}
+
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -5),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+	xs := scene.IntersectWorld(*w, r)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+	if comps.N1 != 1 || comps.N2 != material.Glass {
+		t.Fatalf("Expected the ray to go from vacuum into glass, but got n1 = %v, n2 = %v", comps.N1, comps.N2)
+	}
+
+	c := scene.RefractedColor(*w, *comps, 5)
+
+	// half of the light goes into the pane, and half of that comes out
+	expected := color.NewColor(0, 0.25, 0)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected refracted color = %v, but got %v", expected, c)
+	}
+}
+
+func TestShadeHit_TransparentMaterial(t *testing.T) {
+	// Scenario: shade_hit() with a transparent material
+	// Given w ← default_world()
+	w := scene.DefaultWorld()
+
+	// And floor ← plane() with:
+	//   | transform                 | translation(0, -1, 0) |
+	//   | material.transparency     | 0.5                   |
+	//   | material.refractive_index | 1.5                   |
+	floor := shape.NewPlane()
+	floor.Transform = *core.TranslationM(0, -1, 0)
+	floor.Material.Transparency = 0.5
+	floor.Material.RefractiveIndex = 1.5
+
+	// And ball ← sphere() with:
+	//   | material.color     | (1, 0, 0)                  |
+	//   | material.ambient   | 0.5                        |
+	//   | transform          | translation(0, -3.5, -0.5) |
+	ball := shape.UnitSphere()
+	ball.Material.Color = *color.NewColor(1, 0, 0)
+	ball.Material.Ambient = 0.5
+	ball.Transform = *core.TranslationM(0, -3.5, -0.5)
+
+	// And floor and ball are added to w
+	w.Objects = append(w.Objects, floor, ball)
+
+	// And r ← ray(point(0, 0, -3), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -3),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And xs ← intersections(√2:floor)
+	xs := []rayt.Intersection{rayt.NewIntersection(math.Sqrt2, floor)}
+
+	// When comps ← prepare_computations(xs[0], r, xs)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+
+	// And color ← shade_hit(w, comps, 5)
+	c := scene.ShadeHit(*w, *comps, 5)
+
+	// Then color = color(0.93642, 0.68642, 0.68642)
+	expected := color.NewColor(0.93642, 0.68642, 0.68642)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected shade_hit result = %v, but got %v", expected, c)
+	}
+}
+
+func TestSchlick_TotalInternalReflection(t *testing.T) {
+	// Scenario: The Schlick approximation under total internal reflection
+	// Given shape ← glass_sphere()
+	s := newGlassSphere()
+
+	// And r ← ray(point(0, 0, √2/2), vector(0, 1, 0))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, math.Sqrt2/2),
+		Direction: *core.NewVector(0, 1, 0),
+	}
+
+	// And xs ← intersections(-√2/2:shape, √2/2:shape)
+	xs := []rayt.Intersection{
+		rayt.NewIntersection(-math.Sqrt2/2, s),
+		rayt.NewIntersection(math.Sqrt2/2, s),
+	}
+
+	// When comps ← prepare_computations(xs[1], r, xs)
+	comps := scene.PrepareComputations(xs[1], r, xs)
+
+	// And reflectance ← schlick(comps)
+	reflectance := scene.Schlick(*comps)
+
+	// Then reflectance = 1.0
+	if !core.IsFloatEqual(reflectance, 1.0) {
+		t.Errorf("Expected reflectance = 1.0, but got %v", reflectance)
+	}
+}
+
+func TestSchlick_PerpendicularViewingAngle(t *testing.T) {
+	// Scenario: The Schlick approximation with a perpendicular viewing angle
+	// Given shape ← glass_sphere()
+	s := newGlassSphere()
+
+	// And r ← ray(point(0, 0, 0), vector(0, 1, 0))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, 0),
+		Direction: *core.NewVector(0, 1, 0),
+	}
+
+	// And xs ← intersections(-1:shape, 1:shape)
+	xs := []rayt.Intersection{rayt.NewIntersection(-1, s), rayt.NewIntersection(1, s)}
+
+	// When comps ← prepare_computations(xs[1], r, xs)
+	comps := scene.PrepareComputations(xs[1], r, xs)
+
+	// And reflectance ← schlick(comps)
+	reflectance := scene.Schlick(*comps)
+
+	// Then reflectance = 0.04
+	if !core.IsFloatEqual(reflectance, 0.04) {
+		t.Errorf("Expected reflectance = 0.04, but got %v", reflectance)
+	}
+}
+
+func TestSchlick_SmallAngle(t *testing.T) {
+	// Scenario: The Schlick approximation with small angle and n2 > n1
+	// Given shape ← glass_sphere()
+	s := newGlassSphere()
+
+	// And r ← ray(point(0, 0.99, -2), vector(0, 0, 1))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0.99, -2),
+		Direction: *core.NewVector(0, 0, 1),
+	}
+
+	// And xs ← intersections(1.8589:shape)
+	xs := []rayt.Intersection{rayt.NewIntersection(1.8589, s)}
+
+	// When comps ← prepare_computations(xs[0], r, xs)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+
+	// And reflectance ← schlick(comps)
+	reflectance := scene.Schlick(*comps)
+
+	// Then reflectance = 0.48873
+	if !core.IsFloatEqual(reflectance, 0.48873) {
+		t.Errorf("Expected reflectance = 0.48873, but got %v", reflectance)
+	}
+}
+
+func TestMaterialWithoutRefractiveIndex(t *testing.T) {
+	// a reflective, transparent sphere whose material is built without
+	// DefaultMaterial, so its refractive index is left at 0
+	w := scene.DefaultWorld()
+	s := shape.UnitSphere()
+	s.Transform = *core.TranslationM(0, 0, -3)
+	s.Material = material.Material{
+		Color:        *color.NewColor(0.2, 0.2, 0.2),
+		Ambient:      0.1,
+		Diffuse:      0.5,
+		Reflective:   0.9,
+		Transparency: 0.9,
+	}
+	w.Objects = append(w.Objects, s)
+
+	// the material is treated as vacuum
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0.3, -6), Direction: *core.NewVector(0, 0, 1)}
+	xs := scene.IntersectWorld(*w, r)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+	if comps.N1 != material.Vacuum || comps.N2 != material.Vacuum {
+		t.Errorf("Expected n1 = n2 = vacuum, but got %v and %v", comps.N1, comps.N2)
+	}
+
+	c := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
+	s.Material.RefractiveIndex = material.Vacuum
+	expected := scene.ColorAt(*w, r, scene.MaxReflectionDepth)
+	if math.IsNaN(c.R) || !c.IsEqual(expected) {
+		t.Errorf("Expected color = %v, but got %v", expected, c)
+	}
+}
+
+func TestShadeHit_ReflectiveTransparentMaterial(t *testing.T) {
+	// Scenario: shade_hit() with a reflective, transparent material
+	// Given w ← default_world()
+	w := scene.DefaultWorld()
+
+	// And r ← ray(point(0, 0, -3), vector(0, -√2/2, √2/2))
+	r := rayt.Ray{
+		Origin:    *core.NewPoint(0, 0, -3),
+		Direction: *core.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2),
+	}
+
+	// And floor ← plane() with:
+	//   | transform                 | translation(0, -1, 0) |
+	//   | material.reflective       | 0.5                   |
+	//   | material.transparency     | 0.5                   |
+	//   | material.refractive_index | 1.5                   |
+	floor := shape.NewPlane()
+	floor.Transform = *core.TranslationM(0, -1, 0)
+	floor.Material.Reflective = 0.5
+	floor.Material.Transparency = 0.5
+	floor.Material.RefractiveIndex = 1.5
+
+	// And ball ← sphere() with:
+	//   | material.color     | (1, 0, 0)                  |
+	//   | material.ambient   | 0.5                        |
+	//   | transform          | translation(0, -3.5, -0.5) |
+	ball := shape.UnitSphere()
+	ball.Material.Color = *color.NewColor(1, 0, 0)
+	ball.Material.Ambient = 0.5
+	ball.Transform = *core.TranslationM(0, -3.5, -0.5)
+
+	// And floor and ball are added to w
+	w.Objects = append(w.Objects, floor, ball)
+
+	// And xs ← intersections(√2:floor)
+	xs := []rayt.Intersection{rayt.NewIntersection(math.Sqrt2, floor)}
+
+	// When comps ← prepare_computations(xs[0], r, xs)
+	comps := scene.PrepareComputations(xs[0], r, xs)
+
+	// And color ← shade_hit(w, comps, 5)
+	c := scene.ShadeHit(*w, *comps, 5)
+
+	// Then color = color(0.93391, 0.69643, 0.69243)
+	expected := color.NewColor(0.93391, 0.69643, 0.69243)
+	if !c.IsEqual(*expected) {
+		t.Errorf("Expected shade_hit result = %v, but got %v", expected, c)
+	}
+}
+
+/* ------------- Patterns --------------- */
+
+var (
+	white = *color.NewColor(1, 1, 1)
+	black = *color.NewColor(0, 0, 0)
+)
+
+func TestStripePattern(t *testing.T) {
+	// Scenario: Creating a stripe pattern
+	// Given pattern ← stripe_pattern(white, black)
+	p := pattern.NewStripe(white, black)
+	// Then pattern.a = white
+	// And pattern.b = black
+	origin := *core.NewPoint(0, 0, 0)
+	if a, b := p.A.LocalColorAt(origin), p.B.LocalColorAt(origin); a != white || b != black {
+		t.Errorf("Expected pattern colors = white, black, but got %v, %v", a, b)
+	}
+	// Scenario: The default pattern transformation
+	// Then pattern.transform = identity_matrix
+	if !p.Transform.IsEqual(*core.IdentityMatrix()) {
+		t.Errorf("Expected pattern.transform = identity, but got %v", p.Transform)
+	}
+
+	tests := []struct {
+		scenario string
+		point    *core.Point
+		expected color.Color
+	}{
+		// Scenario: A stripe pattern is constant in y
+		{"constant in y", core.NewPoint(0, 0, 0), white},
+		{"constant in y", core.NewPoint(0, 1, 0), white},
+		{"constant in y", core.NewPoint(0, 2, 0), white},
+		// Scenario: A stripe pattern is constant in z
+		{"constant in z", core.NewPoint(0, 0, 1), white},
+		{"constant in z", core.NewPoint(0, 0, 2), white},
+		// Scenario: A stripe pattern alternates in x
+		{"alternates in x", core.NewPoint(0.9, 0, 0), white},
+		{"alternates in x", core.NewPoint(1, 0, 0), black},
+		{"alternates in x", core.NewPoint(-0.1, 0, 0), black},
+		{"alternates in x", core.NewPoint(-1, 0, 0), black},
+		{"alternates in x", core.NewPoint(-1.1, 0, 0), white},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); c != test.expected {
+			t.Errorf("%s: Expected stripe_at(%v) = %v, but got %v", test.scenario, test.point, test.expected, c)
+		}
+	}
+}
+
+func TestLighting_PatternApplied(t *testing.T) {
+	// Scenario: Lighting with a pattern applied
+	// Given m.pattern ← stripe_pattern(color(1, 1, 1), color(0, 0, 0))
+	// And m.ambient ← 1
+	// And m.diffuse ← 0
+	// And m.specular ← 0
+	m := material.DefaultMaterial()
+	m.Pattern = pattern.NewStripe(white, black)
+	m.Ambient = 1
+	m.Diffuse = 0
+	m.Specular = 0
+
+	// And eyev ← vector(0, 0, -1)
+	// And normalv ← vector(0, 0, -1)
+	// And light ← point_light(point(0, 0, -10), color(1, 1, 1))
+	eyev := core.NewVector(0, 0, -1)
+	normalv := core.NewVector(0, 0, -1)
+	light := lighting.NewPointLight(white, *core.NewPoint(0, 0, -10))
+	s := shape.UnitSphere()
+
+	// When c1 ← lighting(m, light, point(0.9, 0, 0), eyev, normalv, false)
+	// And c2 ← lighting(m, light, point(1.1, 0, 0), eyev, normalv, false)
+	c1 := lighting.Lighting(m, s, light, *core.NewPoint(0.9, 0, 0), *eyev, *normalv, 1)
+	c2 := lighting.Lighting(m, s, light, *core.NewPoint(1.1, 0, 0), *eyev, *normalv, 1)
+
+	// Then c1 = color(1, 1, 1)
+	// And c2 = color(0, 0, 0)
+	if !c1.IsEqual(white) || !c2.IsEqual(black) {
+		t.Errorf("Expected c1 = white and c2 = black, but got %v and %v", c1, c2)
+	}
+}
+
+func TestPatternAtShape(t *testing.T) {
+	// Scenario: Stripes with an object transformation
+	// Given object ← sphere()
+	// And set_transform(object, scaling(2, 2, 2))
+	// And pattern ← stripe_pattern(white, black)
+	// When c ← stripe_at_object(pattern, object, point(1.5, 0, 0))
+	// Then c = white
+	object := shape.UnitSphere()
+	object.Transform = *core.ScaleM(2, 2, 2)
+	p := pattern.NewStripe(white, black)
+	if c := lighting.PatternAtShape(p, object, *core.NewPoint(1.5, 0, 0)); c != white {
+		t.Errorf("object transformation: Expected c = white, but got %v", c)
+	}
+
+	// Scenario: Stripes with a pattern transformation
+	// Given object ← sphere()
+	// And pattern ← stripe_pattern(white, black)
+	// And set_pattern_transform(pattern, scaling(2, 2, 2))
+	// When c ← stripe_at_object(pattern, object, point(1.5, 0, 0))
+	// Then c = white
+	object = shape.UnitSphere()
+	p = pattern.NewStripe(white, black)
+	p.Transform = *core.ScaleM(2, 2, 2)
+	if c := lighting.PatternAtShape(p, object, *core.NewPoint(1.5, 0, 0)); c != white {
+		t.Errorf("pattern transformation: Expected c = white, but got %v", c)
+	}
+
+	// Scenario: Stripes with both an object and a pattern transformation
+	// Given object ← sphere()
+	// And set_transform(object, scaling(2, 2, 2))
+	// And pattern ← stripe_pattern(white, black)
+	// And set_pattern_transform(pattern, translation(0.5, 0, 0))
+	// When c ← stripe_at_object(pattern, object, point(2.5, 0, 0))
+	// Then c = white
+	object = shape.UnitSphere()
+	object.Transform = *core.ScaleM(2, 2, 2)
+	p = pattern.NewStripe(white, black)
+	p.Transform = *core.TranslationM(0.5, 0, 0)
+	if c := lighting.PatternAtShape(p, object, *core.NewPoint(2.5, 0, 0)); c != white {
+		t.Errorf("object and pattern transformation: Expected c = white, but got %v", c)
+	}
+
+	// the transforms of the groups the object is in are applied as well
+	g := shape.NewGroup()
+	g.Transform = *core.TranslationM(10, 0, 0)
+	object = shape.UnitSphere()
+	g.AddChild(object)
+	p = pattern.NewStripe(white, black)
+	if c := lighting.PatternAtShape(p, object, *core.NewPoint(11.5, 0, 0)); c != black {
+		t.Errorf("object in group: Expected c = black, but got %v", c)
+	}
+}
+
+func TestGradientPattern(t *testing.T) {
+	// Scenario: A gradient linearly interpolates between colors
+	// Given pattern ← gradient_pattern(white, black)
+	p := pattern.NewGradient(white, black)
+
+	// Then pattern_at(pattern, point(0, 0, 0)) = white
+	// And pattern_at(pattern, point(0.25, 0, 0)) = color(0.75, 0.75, 0.75)
+	// And pattern_at(pattern, point(0.5, 0, 0)) = color(0.5, 0.5, 0.5)
+	// And pattern_at(pattern, point(0.75, 0, 0)) = color(0.25, 0.25, 0.25)
+	tests := []struct {
+		point    *core.Point
+		expected *color.Color
+	}{
+		{core.NewPoint(0, 0, 0), &white},
+		{core.NewPoint(0.25, 0, 0), color.NewColor(0.75, 0.75, 0.75)},
+		{core.NewPoint(0.5, 0, 0), color.NewColor(0.5, 0.5, 0.5)},
+		{core.NewPoint(0.75, 0, 0), color.NewColor(0.25, 0.25, 0.25)},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); !c.IsEqual(*test.expected) {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+func TestRingPattern(t *testing.T) {
+	// Scenario: A ring should extend in both x and z
+	// Given pattern ← ring_pattern(white, black)
+	p := pattern.NewRing(white, black)
+
+	// Then pattern_at(pattern, point(0, 0, 0)) = white
+	// And pattern_at(pattern, point(1, 0, 0)) = black
+	// And pattern_at(pattern, point(0, 0, 1)) = black
+	// # 0.708 = just slightly more than √2/2
+	// And pattern_at(pattern, point(0.708, 0, 0.708)) = black
+	tests := []struct {
+		point    *core.Point
+		expected color.Color
+	}{
+		{core.NewPoint(0, 0, 0), white},
+		{core.NewPoint(1, 0, 0), black},
+		{core.NewPoint(0, 0, 1), black},
+		{core.NewPoint(0.708, 0, 0.708), black},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); c != test.expected {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+func TestCheckersPattern(t *testing.T) {
+	// Given pattern ← checkers_pattern(white, black)
+	p := pattern.NewChecker(white, black)
+
+	tests := []struct {
+		scenario string
+		point    *core.Point
+		expected color.Color
+	}{
+		// Scenario: Checkers should repeat in x
+		{"repeat in x", core.NewPoint(0, 0, 0), white},
+		{"repeat in x", core.NewPoint(0.99, 0, 0), white},
+		{"repeat in x", core.NewPoint(1.01, 0, 0), black},
+		// Scenario: Checkers should repeat in y
+		{"repeat in y", core.NewPoint(0, 0.99, 0), white},
+		{"repeat in y", core.NewPoint(0, 1.01, 0), black},
+		// Scenario: Checkers should repeat in z
+		{"repeat in z", core.NewPoint(0, 0, 0.99), white},
+		{"repeat in z", core.NewPoint(0, 0, 1.01), black},
+		// points that land just below a boundary because of rounding (like
+		// hits on a plane at y = 0) don't flip to the other color
+		{"rounding", core.NewPoint(0.5, -1e-12, 0.5), white},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); c != test.expected {
+			t.Errorf("%s: Expected pattern_at(%v) = %v, but got %v", test.scenario, test.point, test.expected, c)
+		}
+	}
+}
+
+func TestNestedPatterns(t *testing.T) {
+	red := *color.NewColor(1, 0, 0)
+	green := *color.NewColor(0, 1, 0)
+
+	// checkers whose squares are stripes: the white squares have red and
+	// green stripes along x, the black squares have narrower black and white
+	// stripes, rotated around y so that they alternate along z
+	p := pattern.NewChecker(white, black)
+	p.A = pattern.NewStripe(red, green)
+	inner := pattern.NewStripe(white, black)
+	inner.Transform = *core.ChainTransforms([]*core.Matrix{core.ScaleM(0.25, 1, 1), core.RotateYM(-math.Pi / 2)})
+	p.B = inner
+
+	tests := []struct {
+		point    *core.Point
+		expected color.Color
+	}{
+		// white squares: stripes along x, a unit wide
+		{core.NewPoint(0.5, 0.5, 0.5), red},
+		{core.NewPoint(1.5, 0.5, 1.5), green},
+		{core.NewPoint(2.5, 0.5, 0.5), red},
+		// black square: stripes a quarter unit wide along z
+		{core.NewPoint(1.5, 0.5, 0.1), white},
+		{core.NewPoint(1.5, 0.5, 0.35), black},
+		{core.NewPoint(1.5, 0.5, 0.6), white},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); !c.IsEqual(test.expected) {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+
+	// the nested pattern is carried along by the transform of its parent
+	p.Transform = *core.TranslationM(1, 0, 0)
+	if c := pattern.ColorAt(p, *core.NewPoint(1.5, 0.5, 0.5)); !c.IsEqual(red) {
+		t.Errorf("Expected the translated nested pattern = %v, but got %v", red, c)
+	}
+}
+
+func TestBlendPattern(t *testing.T) {
+	red := *color.NewColor(1, 0, 0)
+
+	// two stripe patterns at right angles blend into a plaid
+	horizontal := pattern.NewStripe(white, red)
+	vertical := pattern.NewStripe(white, red)
+	vertical.Transform = *core.RotateYM(-math.Pi / 2)
+	p := pattern.NewBlend(horizontal, vertical)
+
+	tests := []struct {
+		point    *core.Point
+		expected *color.Color
+	}{
+		{core.NewPoint(0.5, 0, 0.5), color.NewColor(1, 1, 1)},
+		{core.NewPoint(1.5, 0, 0.5), color.NewColor(1, 0.5, 0.5)},
+		{core.NewPoint(0.5, 0, 1.5), color.NewColor(1, 0.5, 0.5)},
+		{core.NewPoint(1.5, 0, 1.5), color.NewColor(1, 0, 0)},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); !c.IsEqual(*test.expected) {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+func TestPerlinNoise(t *testing.T) {
+	// noise is 0 on the integer lattice
+	for _, p := range [][3]float64{{0, 0, 0}, {1, 2, 3}, {-4, 5, -6}} {
+		if n := noise.Perlin(p[0], p[1], p[2]); n != 0 {
+			t.Errorf("Expected noise at %v = 0, but got %v", p, n)
+		}
+	}
+
+	// is deterministic, bounded, and varies smoothly between the lattice points
+	varies := false
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.137, float64(i)*0.071, float64(i)*-0.053
+		n := noise.Perlin(x, y, z)
+		if n != noise.Perlin(x, y, z) {
+			t.Fatalf("Expected noise at (%v, %v, %v) to be the same every time", x, y, z)
+		}
+		if n < -1 || n > 1 {
+			t.Errorf("Expected noise at (%v, %v, %v) within [-1, 1], but got %v", x, y, z, n)
+		}
+		if math.Abs(noise.Perlin(x+1e-4, y, z)-n) > 1e-3 {
+			t.Errorf("Expected noise to be continuous at (%v, %v, %v)", x, y, z)
+		}
+		varies = varies || math.Abs(n) > 0.1
+	}
+	if !varies {
+		t.Errorf("Expected noise to vary between the lattice points")
+	}
+}
+
+func TestPerturbPattern(t *testing.T) {
+	p := pattern.NewStripe(white, black)
+
+	// without any scale, the perturbed pattern is the pattern
+	unperturbed := pattern.NewPerturb(p, 0)
+	perturbed := pattern.NewPerturb(p, 0.5)
+
+	changed := 0
+	for i := 0; i < 200; i++ {
+		point := core.NewPoint(float64(i)*0.05, 0.3, 0.7)
+		if c := unperturbed.LocalColorAt(*point); c != p.LocalColorAt(*point) {
+			t.Errorf("Expected an unscaled perturbation to leave the pattern unchanged at %v", point)
+		}
+		if perturbed.LocalColorAt(*point) != p.LocalColorAt(*point) {
+			changed++
+		}
+	}
+
+	// the stripes become wavy: some points near the edges of the stripes
+	// change color, but most keep theirs
+	if changed == 0 || changed > 100 {
+		t.Errorf("Expected some of the points to change color, but %d out of 200 did", changed)
+	}
+}
+
+/* ------------- Noise and procedural textures --------------- */
+
+func TestSeededNoise(t *testing.T) {
+	a, b, other := noise.New(1), noise.New(1), noise.New(2)
+
+	differs := false
+	for i := 0; i < 100; i++ {
+		x, y, z := float64(i)*0.31, float64(i)*0.17, float64(i)*0.05
+		// the default noise is the one of the package level functions
+		if noise.Default().Perlin(x, y, z) != noise.Perlin(x, y, z) || noise.Default().Simplex(x, y, z) != noise.Simplex(x, y, z) {
+			t.Fatalf("Expected the default noise to match the package level functions at (%v, %v, %v)", x, y, z)
+		}
+		// the same seed gives the same noise
+		if a.Perlin(x, y, z) != b.Perlin(x, y, z) || a.Simplex(x, y, z) != b.Simplex(x, y, z) {
+			t.Fatalf("Expected noise with the same seed to match at (%v, %v, %v)", x, y, z)
+		}
+		differs = differs || a.Perlin(x, y, z) != other.Perlin(x, y, z)
+	}
+	if !differs {
+		t.Errorf("Expected noise with different seeds to differ")
+	}
+}
+
+func TestNoiseReferenceValues(t *testing.T) {
+	// Pinned values, so that any change to the permutation tables, the
+	// gradients or the shuffling of seeded tables (which would change every
+	// procedural texture) is noticed. The Perlin noise at (3.14, 42, 7) is the
+	// value given by Ken Perlin's reference implementation
+	seeded := noise.New(42)
+	tests := []struct {
+		x, y, z                     float64
+		perlin, simplex             float64
+		seededPerlin, seededSimplex float64
+		fbm, turbulence             float64
+	}{
+		{3.14, 42, 7, 0.1369199588, 0.0021676720, 0, -0.6680324495, 0.1060028570, 0.0942810973},
+		{0.5, 0.25, 0.75, -0.4098787308, 0.4824312500, -0.1674604416, -0.3525781250, -0.2186019897, 0.2783544444},
+		{-1.3, 2.7, 0.4, -0.1462196852, 0.2200010240, 0.3265158555, 0.6498187200, -0.1859832080, 0.3937019975},
+	}
+	for _, test := range tests {
+		x, y, z := test.x, test.y, test.z
+		values := []struct {
+			name          string
+			value, expect float64
+		}{
+			{"perlin", noise.Perlin(x, y, z), test.perlin},
+			{"simplex", noise.Simplex(x, y, z), test.simplex},
+			{"seeded perlin", seeded.Perlin(x, y, z), test.seededPerlin},
+			{"seeded simplex", seeded.Simplex(x, y, z), test.seededSimplex},
+			{"fbm", noise.FBM(noise.Perlin, x, y, z, 4), test.fbm},
+			{"turbulence", noise.Turbulence(noise.Simplex, x, y, z, 4), test.turbulence},
+		}
+		for _, v := range values {
+			if math.Abs(v.value-v.expect) > 1e-9 {
+				t.Errorf("Expected %s at (%v, %v, %v) = %v, but got %v", v.name, x, y, z, v.expect, v.value)
+			}
+		}
+	}
+}
+
+func TestSimplexNoise(t *testing.T) {
+	varies := false
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.137, float64(i)*0.071, float64(i)*-0.053
+		n := noise.Simplex(x, y, z)
+		if n < -1 || n > 1 {
+			t.Errorf("Expected noise at (%v, %v, %v) within [-1, 1], but got %v", x, y, z, n)
+		}
+		if math.Abs(noise.Simplex(x+1e-4, y, z)-n) > 1e-2 {
+			t.Errorf("Expected noise to be continuous at (%v, %v, %v)", x, y, z)
+		}
+		varies = varies || math.Abs(n) > 0.1
+	}
+	if !varies {
+		t.Errorf("Expected noise to vary")
+	}
+}
+
+func TestFractalNoise(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		x, y, z := float64(i)*0.137, float64(i)*0.071, float64(i)*-0.053
+
+		// a single octave is the noise itself
+		if n := noise.FBM(noise.Perlin, x, y, z, 1); n != noise.Perlin(x, y, z) {
+			t.Errorf("Expected fbm with 1 octave = perlin at (%v, %v, %v), but got %v", x, y, z, n)
+		}
+		if n := noise.Turbulence(noise.Simplex, x, y, z, 1); n != math.Abs(noise.Simplex(x, y, z)) {
+			t.Errorf("Expected turbulence with 1 octave = |simplex| at (%v, %v, %v), but got %v", x, y, z, n)
+		}
+
+		// more octaves stay within the range of the noise
+		if n := noise.FBM(noise.Simplex, x, y, z, 6); n < -1 || n > 1 {
+			t.Errorf("Expected fbm within [-1, 1], but got %v", n)
+		}
+		if n := noise.Turbulence(noise.Perlin, x, y, z, 6); n < 0 || n > 1 {
+			t.Errorf("Expected turbulence within [0, 1], but got %v", n)
+		}
+	}
+
+	// octaves add detail: the fbm differs from the plain noise
+	if noise.FBM(noise.Perlin, 0.3, 0.6, 0.9, 4) == noise.Perlin(0.3, 0.6, 0.9) {
+		t.Errorf("Expected octaves to add detail to the noise")
+	}
+}
+
+func TestMarblePattern(t *testing.T) {
+	// without turbulence, the marble is a sine wave of bands along x
+	p := pattern.NewMarble(white, black)
+	p.Turbulence = 0
+	tests := []struct {
+		point    *core.Point
+		expected *color.Color
+	}{
+		{core.NewPoint(0, 0, 0), color.NewColor(0.5, 0.5, 0.5)},
+		{core.NewPoint(math.Pi/2, 3, 4), color.NewColor(0, 0, 0)},
+		{core.NewPoint(-math.Pi/2, 0, -1), color.NewColor(1, 1, 1)},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); !c.IsEqual(*test.expected) {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+func TestWoodPattern(t *testing.T) {
+	// without turbulence, the rings fade from a to b every unit from the y axis
+	p := pattern.NewWood(white, black)
+	p.Turbulence = 0
+	tests := []struct {
+		point    *core.Point
+		expected *color.Color
+	}{
+		{core.NewPoint(0, 0, 0), color.NewColor(1, 1, 1)},
+		{core.NewPoint(0.25, 5, 0), color.NewColor(0.75, 0.75, 0.75)},
+		{core.NewPoint(0, -1, 1.5), color.NewColor(0.5, 0.5, 0.5)},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); !c.IsEqual(*test.expected) {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+func TestProceduralTexturesAreDeterministic(t *testing.T) {
+	textures := map[string]func() pattern.Pattern{
+		"marble":  func() pattern.Pattern { return pattern.NewMarble(white, black) },
+		"wood":    func() pattern.Pattern { return pattern.NewWood(white, black) },
+		"granite": func() pattern.Pattern { return pattern.NewGranite(white, black) },
+	}
+	seeded := map[string]func() pattern.Pattern{
+		"marble": func() pattern.Pattern {
+			p := pattern.NewMarble(white, black)
+			p.Noise = noise.New(7)
+			return p
+		},
+		"wood": func() pattern.Pattern {
+			p := pattern.NewWood(white, black)
+			p.Noise = noise.New(7)
+			return p
+		},
+		"granite": func() pattern.Pattern {
+			p := pattern.NewGranite(white, black)
+			p.Noise = noise.New(7)
+			return p
+		},
+	}
+
+	for name, newTexture := range textures {
+		a, b, other := newTexture(), newTexture(), seeded[name]()
+		differs := false
+		for i := 0; i < 200; i++ {
+			point := core.NewPoint(float64(i)*0.113, float64(i)*0.07, float64(i)*-0.031)
+			c := a.LocalColorAt(*point)
+			if c != b.LocalColorAt(*point) {
+				t.Fatalf("%s: Expected the same color every time at %v", name, point)
+			}
+			// the texture mixes between a and b
+			if c.R < 0 || c.R > 1 || c.R != c.G || c.G != c.B {
+				t.Errorf("%s: Expected a gray between black and white at %v, but got %v", name, point, c)
+			}
+			differs = differs || other.LocalColorAt(*point) != c
+		}
+		if !differs {
+			t.Errorf("%s: Expected a different noise seed to give a different texture", name)
+		}
+	}
+}
+
+func TestProceduralTextureReferenceValues(t *testing.T) {
+	// Pinned values of the textures between black and white, see
+	// TestNoiseReferenceValues
+	seededMarble := pattern.NewMarble(white, black)
+	seededMarble.Noise = noise.New(7)
+	textures := []struct {
+		name     string
+		texture  pattern.Pattern
+		expected [2]float64
+	}{
+		{"marble", pattern.NewMarble(white, black), [2]float64{0.0253160513, 0.9748642977}},
+		{"wood", pattern.NewWood(white, black), [2]float64{0.9002732073, 0.1952579061}},
+		{"granite", pattern.NewGranite(white, black), [2]float64{0.0418993447, 0.6966086829}},
+		{"seeded marble", seededMarble, [2]float64{0.0182721881, 0.7000217276}},
+	}
+	points := []*core.Point{core.NewPoint(0.3, 0.6, 0.9), core.NewPoint(-1.7, 2.2, 0.45)}
+	for _, test := range textures {
+		for i, point := range points {
+			if c := test.texture.LocalColorAt(*point); math.Abs(c.R-test.expected[i]) > 1e-9 {
+				t.Errorf("Expected %s at %v = %v, but got %v", test.name, point, test.expected[i], c.R)
+			}
+		}
+	}
+}
+
+func TestRenderingProceduralTextureIsStable(t *testing.T) {
+	newScene := func() (*scene.Camera, *scene.World) {
+		c, w := newFlatSphereScene()
+		s := w.Objects[0].GetMaterial()
+		s.Pattern = pattern.NewMarble(*color.NewColor(0.9, 0.9, 0.85), *color.NewColor(0.2, 0.25, 0.3))
+		return c, w
+	}
+
+	c, w := newScene()
+	expected := scene.Render(*c, *w)
+
+	// pinned pixels of the render, see TestNoiseReferenceValues
+	golden := []struct {
+		x, y  int
+		color *color.Color
+	}{
+		{7, 7, color.NewColor(0.5499813333, 0.5749826667, 0.5749853333)},
+		{5, 8, color.NewColor(0.2001523618, 0.2501414788, 0.3001197128)},
+		{9, 6, color.NewColor(0.2212749635, 0.2697553232, 0.3167160427)},
+	}
+	for _, g := range golden {
+		if pixel := expected.PixelAt(g.x, g.y); !pixel.IsEqual(*g.color) {
+			t.Errorf("Expected pixel (%d, %d) = %v, but got %v", g.x, g.y, g.color, pixel)
+		}
+	}
+
+	c, w = newScene()
+	image := scene.RenderParallel(*c, *w, scene.RenderOptions{Workers: 3, TileSize: 4})
+	for y := 0; y < c.Vsize; y++ {
+		for x := 0; x < c.Hsize; x++ {
+			if image.PixelAt(x, y) != expected.PixelAt(x, y) {
+				t.Fatalf("Expected pixel (%d, %d) = %v, but got %v", x, y, expected.PixelAt(x, y), image.PixelAt(x, y))
+			}
+		}
+	}
+}
+
+/* ------------- Texture mapping --------------- */
+
+func TestUVCheckersPattern(t *testing.T) {
+	// Scenario Outline: Checker pattern in 2D
+	// Given checkers ← uv_checkers(2, 2, black, white)
+	checkers := pattern.NewUVCheckers(2, 2, black, white)
+
+	// When color ← uv_pattern_at(checkers, <u>, <v>)
+	// Then color = <expected>
+	tests := []struct {
+		u, v     float64
+		expected color.Color
+	}{
+		{0.0, 0.0, black},
+		{0.5, 0.0, white},
+		{0.0, 0.5, white},
+		{0.5, 0.5, black},
+		{1.0, 1.0, black},
+	}
+	for _, test := range tests {
+		if c := checkers.UVColorAt(test.u, test.v); c != test.expected {
+			t.Errorf("Expected uv_pattern_at(%v, %v) = %v, but got %v", test.u, test.v, test.expected, c)
+		}
+	}
+}
+
+type uvMapTest struct {
+	point *core.Point
+	u, v  float64
+}
+
+func checkUVMap(t *testing.T, name string, mapping pattern.UVMap, tests []uvMapTest) {
+	t.Helper()
+	for _, test := range tests {
+		u, v := mapping(*test.point)
+		if !core.IsFloatEqual(u, test.u) || !core.IsFloatEqual(v, test.v) {
+			t.Errorf("Expected %s(%v) = (%v, %v), but got (%v, %v)", name, test.point, test.u, test.v, u, v)
+		}
+	}
+}
+
+func TestSphericalMap(t *testing.T) {
+	// Scenario Outline: Using a spherical mapping on a 3D point
+	checkUVMap(t, "spherical_map", pattern.SphericalMap, []uvMapTest{
+		{core.NewPoint(0, 0, -1), 0.0, 0.5},
+		{core.NewPoint(1, 0, 0), 0.25, 0.5},
+		{core.NewPoint(0, 0, 1), 0.5, 0.5},
+		{core.NewPoint(-1, 0, 0), 0.75, 0.5},
+		{core.NewPoint(0, 1, 0), 0.5, 1.0},
+		{core.NewPoint(0, -1, 0), 0.5, 0.0},
+		{core.NewPoint(math.Sqrt2/2, math.Sqrt2/2, 0), 0.25, 0.75},
+	})
+}
+
+func TestPlanarMap(t *testing.T) {
+	// Scenario Outline: Using a planar mapping on a 3D point
+	checkUVMap(t, "planar_map", pattern.PlanarMap, []uvMapTest{
+		{core.NewPoint(0.25, 0, 0.5), 0.25, 0.5},
+		{core.NewPoint(0.25, 0, -0.25), 0.25, 0.75},
+		{core.NewPoint(0.25, 0.5, -0.25), 0.25, 0.75},
+		{core.NewPoint(1.25, 0, 0.5), 0.25, 0.5},
+		{core.NewPoint(0.25, 0, -1.75), 0.25, 0.25},
+		{core.NewPoint(1, 0, -1), 0.0, 0.0},
+		{core.NewPoint(0, 0, 0), 0.0, 0.0},
+	})
+}
+
+func TestCylindricalMap(t *testing.T) {
+	// Scenario Outline: Using a cylindrical mapping on a 3D point
+	checkUVMap(t, "cylindrical_map", pattern.CylindricalMap, []uvMapTest{
+		{core.NewPoint(0, 0, -1), 0.0, 0.0},
+		{core.NewPoint(0, 0.5, -1), 0.0, 0.5},
+		{core.NewPoint(0, 1, -1), 0.0, 0.0},
+		{core.NewPoint(0.70711, 0.5, -0.70711), 0.125, 0.5},
+		{core.NewPoint(1, 0.5, 0), 0.25, 0.5},
+		{core.NewPoint(0.70711, 0.5, 0.70711), 0.375, 0.5},
+		{core.NewPoint(0, -0.25, 1), 0.5, 0.75},
+		{core.NewPoint(-0.70711, 0.5, 0.70711), 0.625, 0.5},
+		{core.NewPoint(-1, 1.25, 0), 0.75, 0.25},
+		{core.NewPoint(-0.70711, 0.5, -0.70711), 0.875, 0.5},
+	})
+}
+
+func TestCubeFaceUV(t *testing.T) {
+	// Scenario Outline: Identifying the face of a cube from a point
+	faces := []struct {
+		point *core.Point
+		face  pattern.CubeFace
+	}{
+		{core.NewPoint(-1, 0.5, -0.25), pattern.CubeLeft},
+		{core.NewPoint(1.1, -0.75, 0.8), pattern.CubeRight},
+		{core.NewPoint(0.1, 0.6, 0.9), pattern.CubeFront},
+		{core.NewPoint(-0.7, 0, -2), pattern.CubeBack},
+		{core.NewPoint(0.5, 1, 0.9), pattern.CubeUp},
+		{core.NewPoint(-0.2, -1.3, 1.1), pattern.CubeDown},
+	}
+	for _, test := range faces {
+		if face, _, _ := pattern.CubeFaceUV(*test.point); face != test.face {
+			t.Errorf("Expected face_from_point(%v) = %v, but got %v", test.point, test.face, face)
+		}
+	}
+
+	// Scenario Outline: UV mapping of the faces of a cube
+	uvs := []struct {
+		point *core.Point
+		u, v  float64
+	}{
+		// front
+		{core.NewPoint(-0.5, 0.5, 1), 0.25, 0.75},
+		{core.NewPoint(0.5, -0.5, 1), 0.75, 0.25},
+		// back
+		{core.NewPoint(0.5, 0.5, -1), 0.25, 0.75},
+		{core.NewPoint(-0.5, -0.5, -1), 0.75, 0.25},
+		// left
+		{core.NewPoint(-1, 0.5, -0.5), 0.25, 0.75},
+		{core.NewPoint(-1, -0.5, 0.5), 0.75, 0.25},
+		// right
+		{core.NewPoint(1, 0.5, 0.5), 0.25, 0.75},
+		{core.NewPoint(1, -0.5, -0.5), 0.75, 0.25},
+		// up
+		{core.NewPoint(-0.5, 1, -0.5), 0.25, 0.75},
+		{core.NewPoint(0.5, 1, 0.5), 0.75, 0.25},
+		// down
+		{core.NewPoint(-0.5, -1, 0.5), 0.25, 0.75},
+		{core.NewPoint(0.5, -1, -0.5), 0.75, 0.25},
+	}
+	for _, test := range uvs {
+		if _, u, v := pattern.CubeFaceUV(*test.point); !core.IsFloatEqual(u, test.u) || !core.IsFloatEqual(v, test.v) {
+			t.Errorf("Expected cube_uv(%v) = (%v, %v), but got (%v, %v)", test.point, test.u, test.v, u, v)
+		}
+	}
+}
+
+func TestCubeMap(t *testing.T) {
+	// the faces are laid out as a cross: the center of each face is at the
+	// center of its cell in a 4 x 3 grid
+	checkUVMap(t, "cube_map", pattern.CubeMap, []uvMapTest{
+		{core.NewPoint(-1, 0, 0), 0.125, 0.5},
+		{core.NewPoint(0, 0, 1), 0.375, 0.5},
+		{core.NewPoint(1, 0, 0), 0.625, 0.5},
+		{core.NewPoint(0, 0, -1), 0.875, 0.5},
+		{core.NewPoint(0, 1, 0), 0.375, 5.0 / 6},
+		{core.NewPoint(0, -1, 0), 0.375, 1.0 / 6},
+	})
+}
+
+func TestTextureMapWithSphericalMap(t *testing.T) {
+	// Scenario Outline: Using a texture map pattern with a spherical map
+	// Given checkers ← uv_checkers(16, 8, black, white)
+	// And pattern ← texture_map(checkers, spherical_map)
+	p := pattern.NewTextureMap(pattern.NewUVCheckers(16, 8, black, white), pattern.SphericalMap)
+
+	// Then pattern_at(pattern, <point>) = <color>
+	tests := []struct {
+		point    *core.Point
+		expected color.Color
+	}{
+		{core.NewPoint(0.4315, 0.4670, 0.7719), white},
+		{core.NewPoint(-0.9654, 0.2552, -0.0534), black},
+		{core.NewPoint(0.1039, 0.7090, 0.6975), white},
+		{core.NewPoint(-0.4986, -0.7856, -0.3663), black},
+		{core.NewPoint(-0.0317, -0.9395, 0.3411), black},
+		{core.NewPoint(0.4809, -0.7721, 0.4154), black},
+		{core.NewPoint(0.0285, -0.9612, -0.2745), black},
+		{core.NewPoint(-0.5734, -0.2162, -0.7903), white},
+		{core.NewPoint(0.7688, -0.1470, 0.6223), black},
+		{core.NewPoint(-0.7652, 0.2175, 0.6060), black},
+	}
+	for _, test := range tests {
+		if c := p.LocalColorAt(*test.point); c != test.expected {
+			t.Errorf("Expected pattern_at(%v) = %v, but got %v", test.point, test.expected, c)
+		}
+	}
+}
+
+// 2 x 2 image: red, green on the top row and blue, white on the bottom one
+func newTestImage() *rendering.Canvas {
+	image := rendering.NewCanvas(2, 2, black)
+	image.WritePixel(0, 0, *color.NewColor(1, 0, 0))
+	image.WritePixel(1, 0, *color.NewColor(0, 1, 0))
+	image.WritePixel(0, 1, *color.NewColor(0, 0, 1))
+	image.WritePixel(1, 1, *color.NewColor(1, 1, 1))
+	return image
+}
+
+func TestImageTexture(t *testing.T) {
+	texture := pattern.NewImageTexture(newTestImage())
+
+	tests := []struct {
+		filter   pattern.TextureFilter
+		u, v     float64
+		expected *color.Color
+	}{
+		// the corners of the image are at the corners of the uv square, with
+		// v going up
+		{pattern.NearestFilter, 0, 0, color.NewColor(0, 0, 1)},
+		{pattern.NearestFilter, 1, 1, color.NewColor(0, 1, 0)},
+		{pattern.NearestFilter, 0, 1, color.NewColor(1, 0, 0)},
+		{pattern.NearestFilter, 1, 0, color.NewColor(1, 1, 1)},
+		// the closest pixel is picked
+		{pattern.NearestFilter, 0.4, 0.6, color.NewColor(1, 0, 0)},
+		{pattern.NearestFilter, 0.6, 0.4, color.NewColor(1, 1, 1)},
+		// the pixels are blended
+		{pattern.BilinearFilter, 0, 0, color.NewColor(0, 0, 1)},
+		{pattern.BilinearFilter, 0.5, 0.5, color.NewColor(0.5, 0.5, 0.5)},
+		{pattern.BilinearFilter, 0.25, 1, color.NewColor(0.75, 0.25, 0)},
+		{pattern.BilinearFilter, 1, 0.25, color.NewColor(0.75, 1, 0.75)},
+	}
+	for _, test := range tests {
+		texture.Filter = test.filter
+		if c := texture.UVColorAt(test.u, test.v); !c.IsEqual(*test.expected) {
+			t.Errorf("filter %v: Expected uv_pattern_at(%v, %v) = %v, but got %v", test.filter, test.u, test.v, test.expected, c)
+		}
+	}
+}
+
+func TestImageTextureOnSphere(t *testing.T) {
+	// an image wrapped around a sphere: the top row of the image is at the
+	// north pole and the bottom row at the south pole
+	s := shape.UnitSphere()
+	s.Transform = *core.ScaleM(2, 2, 2)
+	s.Material.Pattern = pattern.NewTextureMap(pattern.NewImageTexture(newTestImage()), pattern.SphericalMap)
+
+	north := lighting.PatternAtShape(s.Material.Pattern, s, *core.NewPoint(0, 2, 0))
+	south := lighting.PatternAtShape(s.Material.Pattern, s, *core.NewPoint(0, -2, 0))
+	if !north.IsEqual(*color.NewColor(0, 1, 0)) || !south.IsEqual(*color.NewColor(1, 1, 1)) {
+		t.Errorf("Expected the north and south poles = green and white, but got %v and %v", north, south)
+	}
+}
+
+/* ------------- Reading PPM files --------------- */
+
+func TestLoadPPM_WrongMagicNumber(t *testing.T) {
+	// Scenario: Reading a file with the wrong magic number
+	// Given ppm ← a file containing:
+	//   """
+	//   P32
+	//   1 1
+	//   255
+	//   0 0 0
+	//   """
+	ppm := "P32\n1 1\n255\n0 0 0\n"
+	// Then canvas_from_ppm(ppm) should fail
+	_, err := rendering.LoadPPM(strings.NewReader(ppm))
+	if !errors.Is(err, rendering.ErrPPMHeader) {
+		t.Errorf("Expected a header error, but got %v", err)
+	}
+}
+
+func TestLoadPPM_Size(t *testing.T) {
+	// Scenario: Reading a PPM returns a canvas of the right size
+	ppm := "P3\n10 2\n255\n" + strings.Repeat("0 0 0 ", 20) + "\n"
+	// When canvas ← canvas_from_ppm(ppm)
+	canvas, err := rendering.LoadPPM(strings.NewReader(ppm))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	// Then canvas.width = 10
+	// And canvas.height = 2
+	if canvas.Width != 10 || canvas.Height != 2 {
+		t.Errorf("Expected a 10 x 2 canvas, but got %d x %d", canvas.Width, canvas.Height)
+	}
+}
+
+type ppmPixelTest struct {
+	x, y     int
+	expected *color.Color
+}
+
+func checkPPMPixels(t *testing.T, ppm string, tests []ppmPixelTest) {
+	t.Helper()
+	canvas, err := rendering.LoadPPM(strings.NewReader(ppm))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	for _, test := range tests {
+		if c := canvas.PixelAt(test.x, test.y); !c.IsEqual(*test.expected) {
+			t.Errorf("Expected pixel_at(canvas, %d, %d) = %v, but got %v", test.x, test.y, test.expected, c)
+		}
+	}
+}
+
+func TestLoadPPM_PixelData(t *testing.T) {
+	// Scenario Outline: Reading pixel data from a PPM file
+	checkPPMPixels(t, "P3\n4 3\n255\n"+
+		"255 127 0  0 127 255  127 255 0  255 255 255\n"+
+		"0 0 0  255 0 0  0 255 0  0 0 255\n"+
+		"255 255 0  0 255 255  255 0 255  127 127 127\n", []ppmPixelTest{
+		{0, 0, color.NewColor(1, 0.49804, 0)},
+		{1, 0, color.NewColor(0, 0.49804, 1)},
+		{2, 0, color.NewColor(0.49804, 1, 0)},
+		{3, 0, color.NewColor(1, 1, 1)},
+		{0, 1, color.NewColor(0, 0, 0)},
+		{1, 1, color.NewColor(1, 0, 0)},
+		{2, 1, color.NewColor(0, 1, 0)},
+		{3, 1, color.NewColor(0, 0, 1)},
+		{0, 2, color.NewColor(1, 1, 0)},
+		{1, 2, color.NewColor(0, 1, 1)},
+		{2, 2, color.NewColor(1, 0, 1)},
+		{3, 2, color.NewColor(0.49804, 0.49804, 0.49804)},
+	})
+}
+
+func TestLoadPPM_Comments(t *testing.T) {
+	// Scenario: PPM parsing ignores comment lines
+	checkPPMPixels(t, "P3\n# this is a comment\n2 1\n# this, too\n255\n# another comment\n"+
+		"255 255 255\n# oh, no, comments in the pixel data!\n255 0 255\n", []ppmPixelTest{
+		{0, 0, color.NewColor(1, 1, 1)},
+		{1, 0, color.NewColor(1, 0, 1)},
+	})
+
+	// comments can also follow a value on the same line
+	checkPPMPixels(t, "P3 # plain\n1 1 # size\n255# maxval\n10 20 30#pixel\n", []ppmPixelTest{
+		{0, 0, color.NewColor(10.0/255, 20.0/255, 30.0/255)},
+	})
+}
+
+func TestLoadPPM_Whitespace(t *testing.T) {
+	// Scenario: PPM parsing allows an RGB triple to span lines
+	checkPPMPixels(t, "P3\n1 1\n255\n51\n153\n\n204\n", []ppmPixelTest{
+		{0, 0, color.NewColor(0.2, 0.6, 0.8)},
+	})
+
+	// any whitespace separates the values, and the final newline is optional
+	checkPPMPixels(t, "P3\t2\r\n1\f255 \v 51 153\t204\r\n0 0 255", []ppmPixelTest{
+		{0, 0, color.NewColor(0.2, 0.6, 0.8)},
+		{1, 0, color.NewColor(0, 0, 1)},
+	})
+}
+
+func TestLoadPPM_Maxval(t *testing.T) {
+	// Scenario: PPM parsing respects the scale setting
+	checkPPMPixels(t, "P3\n2 2\n100\n100 100 100  50 50 50\n75 50 25  0 0 0\n", []ppmPixelTest{
+		{0, 1, color.NewColor(0.75, 0.5, 0.25)},
+	})
+
+	// maxval can be above 255
+	checkPPMPixels(t, "P3\n1 1\n1000\n250 500 1000\n", []ppmPixelTest{
+		{0, 0, color.NewColor(0.25, 0.5, 1)},
+	})
+}
+
+func TestLoadPPM_Raw(t *testing.T) {
+	// one byte per value, right after the single whitespace following maxval
+	checkPPMPixels(t, "P6\n# raw\n2 1\n255\n\xff\x00\x33\x0a\x20\x80", []ppmPixelTest{
+		{0, 0, color.NewColor(1, 0, 0.2)},
+		{1, 0, color.NewColor(10.0/255, 32.0/255, 128.0/255)},
+	})
+
+	// two bytes per value, most significant first, when maxval > 255
+	checkPPMPixels(t, "P6 1 1 1000\n\x00\xfa\x01\xf4\x03\xe8", []ppmPixelTest{
+		{0, 0, color.NewColor(0.25, 0.5, 1)},
+	})
+}
+
+func TestLoadPPM_Errors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		ppm      string
+		expected error
+		message  string
+	}{
+		{"empty file", "", rendering.ErrPPMHeader, "missing magic number"},
+		{"missing maxval", "P3\n1 1\n", rendering.ErrPPMHeader, "missing maxval"},
+		{"invalid width", "P3\nten 1\n255\n", rendering.ErrPPMHeader, `invalid width "ten" at byte 3`},
+		{"zero height", "P3\n1 0\n255\n", rendering.ErrPPMHeader, "height 0 at byte 5 is out of range"},
+		{"maxval too large", "P3\n1 1\n65536\n0 0 0\n", rendering.ErrPPMHeader, "maxval 65536 at byte 7 is out of range"},
+		{"invalid value", "P3\n1 1\n255\n0 x 0\n", rendering.ErrPPMData, `invalid value "x" at byte 13`},
+		{"value above maxval", "P3\n1 1\n100\n0 101 0\n", rendering.ErrPPMData, "value 101 at byte 13 is out of range"},
+		{"raw value above maxval", "P6\n1 1\n100\n\x00\x00\x65", rendering.ErrPPMData, "value 101 at byte 13 is out of range"},
+		{"truncated plain data", "P3\n2 1\n255\n0 0 0 0\n", rendering.ErrPPMTruncated, "expected 6 values, got 4"},
+		{"truncated raw data", "P6\n2 1\n255\n\x00\x00\x00\x00", rendering.ErrPPMTruncated, "expected 6 bytes, got 4"},
+		{"truncated 16 bit data", "P6\n1 1\n1000\n\x00\x00\x00", rendering.ErrPPMTruncated, "expected 6 bytes, got 3"},
+	}
+
+	for _, test := range tests {
+		_, err := rendering.LoadPPM(strings.NewReader(test.ppm))
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: Expected error %v, but got %v", test.scenario, test.expected, err)
+		} else if !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s: Expected error to mention %q, but got %v", test.scenario, test.message, err)
+		}
+	}
+}
+
+func TestPPMRoundTrip(t *testing.T) {
+	// colors that are exactly representable with a maxval of 255
+	canvas := rendering.NewCanvas(5, 3, *color.Black)
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			canvas.WritePixel(x, y, *color.NewColor(float64(x*51)/255, float64(y*100)/255, float64(x*y*17)/255))
+		}
+	}
+
+	fileName := t.TempDir() + "/canvas.ppm"
+	if err := canvas.WriteToPPM(fileName); err != nil {
+		t.Fatalf("Expected no error writing the canvas, but got %v", err)
+	}
+	loaded, err := rendering.LoadPPMFile(fileName)
+	if err != nil {
+		t.Fatalf("Expected no error reading the canvas back, but got %v", err)
+	}
+
+	if loaded.Width != canvas.Width || loaded.Height != canvas.Height {
+		t.Fatalf("Expected a %d x %d canvas, but got %d x %d", canvas.Width, canvas.Height, loaded.Width, loaded.Height)
+	}
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			if !loaded.PixelAt(x, y).IsEqual(canvas.PixelAt(x, y)) {
+				t.Errorf("Expected pixel (%d, %d) = %v, but got %v", x, y, canvas.PixelAt(x, y), loaded.PixelAt(x, y))
+			}
+		}
+	}
+
+	if _, err := rendering.LoadPPMFile(t.TempDir() + "/missing.ppm"); err == nil {
+		t.Errorf("Expected an error reading a missing file")
+	}
+}
+
+/* ------------- Encoding images --------------- */
+
+func newEncodingTestCanvas() *rendering.Canvas {
+	// Given c ← canvas(5, 3)
+	// And c1 ← color(1.5, 0, 0)
+	// And c2 ← color(0, 0.5, 0)
+	// And c3 ← color(-0.5, 0, 1)
+	// When write_pixel(c, 0, 0, c1)
+	// And write_pixel(c, 2, 1, c2)
+	// And write_pixel(c, 4, 2, c3)
+	c := rendering.NewCanvas(5, 3, *color.Black)
+	c.WritePixel(0, 0, *color.NewColor(1.5, 0, 0))
+	c.WritePixel(2, 1, *color.NewColor(0, 0.5, 0))
+	c.WritePixel(4, 2, *color.NewColor(-0.5, 0, 1))
+	return c
+}
+
+func TestEncodePlainPPM(t *testing.T) {
+	// Scenario: Constructing the PPM header
+	// Scenario: Constructing the PPM pixel data
+	c := newEncodingTestCanvas()
+	var ppm strings.Builder
+	if err := c.EncodePlainPPM(&ppm); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expected := "P3\n5 3\n255\n" +
+		"255 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+		"0 0 0 0 0 0 0 128 0 0 0 0 0 0 0\n" +
+		"0 0 0 0 0 0 0 0 0 0 0 0 0 0 255\n"
+	if ppm.String() != expected {
+		t.Errorf("Expected ppm =\n%s\nbut got\n%s", expected, ppm.String())
+	}
+}
+
+func TestEncodePlainPPM_SplitsLongLines(t *testing.T) {
+	// Scenario: Splitting long lines in PPM files
+	// Given c ← canvas(10, 2)
+	// When every pixel of c is set to color(1, 0.8, 0.6)
+	c := rendering.NewCanvas(10, 2, *color.NewColor(1, 0.8, 0.6))
+	var ppm strings.Builder
+	if err := c.EncodePlainPPM(&ppm); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	// Then lines 4-7 of ppm are
+	expected := "P3\n10 2\n255\n" +
+		"255 204 153 255 204 153 255 204 153 255 204 153 255 204 153 255 204\n" +
+		"153 255 204 153 255 204 153 255 204 153 255 204 153\n" +
+		"255 204 153 255 204 153 255 204 153 255 204 153 255 204 153 255 204\n" +
+		"153 255 204 153 255 204 153 255 204 153 255 204 153\n"
+	if ppm.String() != expected {
+		t.Errorf("Expected ppm =\n%s\nbut got\n%s", expected, ppm.String())
+	}
+	for _, line := range strings.Split(ppm.String(), "\n") {
+		if len(line) > 70 {
+			t.Errorf("Expected lines of at most 70 characters, but got %q", line)
+		}
+	}
+
+	// Scenario: PPM files are terminated by a newline character
+	if !strings.HasSuffix(ppm.String(), "\n") {
+		t.Errorf("Expected ppm to end with a newline")
+	}
+}
+
+func TestEncodeRawPPM(t *testing.T) {
+	c := newEncodingTestCanvas()
+
+	var ppm strings.Builder
+	if err := c.Encode(&ppm, rendering.RawPPM); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	header := "P6\n5 3\n255\n"
+	if !strings.HasPrefix(ppm.String(), header) || len(ppm.String()) != len(header)+5*3*3 {
+		t.Fatalf("Expected a raw ppm of 45 bytes after the header, but got %q", ppm.String())
+	}
+	data := ppm.String()[len(header):]
+	if data[0:3] != "\xff\x00\x00" || data[3*7:3*7+3] != "\x00\x80\x00" || data[3*14:] != "\x00\x00\xff" {
+		t.Errorf("Expected the pixels to be written as bytes, but got %q", data)
+	}
+
+	ppm.Reset()
+	if err := c.Encode(&ppm, rendering.RawPPM16); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	header = "P6\n5 3\n65535\n"
+	if !strings.HasPrefix(ppm.String(), header) || len(ppm.String()) != len(header)+5*3*3*2 {
+		t.Fatalf("Expected a 16 bit raw ppm of 90 bytes after the header, but got %q", ppm.String())
+	}
+	data = ppm.String()[len(header):]
+	if data[0:6] != "\xff\xff\x00\x00\x00\x00" || data[6*7:6*7+6] != "\x00\x00\x80\x00\x00\x00" {
+		t.Errorf("Expected the pixels to be written as pairs of bytes, but got %q", data)
+	}
+
+	if err := c.EncodeRawPPM(&ppm, 70000); err == nil {
+		t.Errorf("Expected an error for a maxval above 65535")
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	// a gradient that needs more than 8 bits per channel
+	c := rendering.NewCanvas(64, 4, *color.Black)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			c.WritePixel(x, y, *color.NewColor(float64(x)/63, float64(y)/3, float64(x*y)/1000))
+		}
+	}
+
+	tests := []struct {
+		format    rendering.ImageFormat
+		tolerance float64
+	}{
+		{rendering.PlainPPM, 0.5 / 255},
+		{rendering.RawPPM, 0.5 / 255},
+		{rendering.RawPPM16, 0.5 / 65535},
+	}
+	for _, test := range tests {
+		var buf strings.Builder
+		if err := c.Encode(&buf, test.format); err != nil {
+			t.Fatalf("format %v: Expected no error, but got %v", test.format, err)
+		}
+		loaded, err := rendering.LoadPPM(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatalf("format %v: Expected no error reading the image back, but got %v", test.format, err)
+		}
+		for y := 0; y < c.Height; y++ {
+			for x := 0; x < c.Width; x++ {
+				expected, got := c.PixelAt(x, y), loaded.PixelAt(x, y)
+				if math.Abs(expected.R-got.R) > test.tolerance || math.Abs(expected.G-got.G) > test.tolerance || math.Abs(expected.B-got.B) > test.tolerance {
+					t.Fatalf("format %v: Expected pixel (%d, %d) = %v, but got %v", test.format, x, y, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	c := newEncodingTestCanvas()
+	var buf strings.Builder
+	if err := c.Encode(&buf, rendering.PNG); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	img, err := png.Decode(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Expected a valid png, but got %v", err)
+	}
+	if img.Bounds().Dx() != 5 || img.Bounds().Dy() != 3 {
+		t.Fatalf("Expected a 5 x 3 image, but got %v", img.Bounds())
+	}
+	tests := []struct {
+		x, y    int
+		r, g, b uint8
+	}{
+		{0, 0, 255, 0, 0},
+		{2, 1, 0, 128, 0},
+		{4, 2, 0, 0, 255},
+		{1, 1, 0, 0, 0},
+	}
+	for _, test := range tests {
+		got := imageColor.NRGBAModel.Convert(img.At(test.x, test.y)).(imageColor.NRGBA)
+		expected := imageColor.NRGBA{R: test.r, G: test.g, B: test.b, A: 255}
+		if got != expected {
+			t.Errorf("Expected pixel (%d, %d) = %v, but got %v", test.x, test.y, expected, got)
+		}
+	}
+}
+
+// writer that fails after accepting n bytes
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncodeReturnsWriteErrors(t *testing.T) {
+	c := rendering.NewCanvas(100, 100, *color.NewColor(0.5, 0.5, 0.5))
+	for _, format := range []rendering.ImageFormat{rendering.PlainPPM, rendering.RawPPM, rendering.RawPPM16, rendering.PNG} {
+		if err := c.Encode(&failingWriter{n: 10}, format); err == nil || err.Error() != "disk full" {
+			t.Errorf("format %v: Expected the error of the writer, but got %v", format, err)
+		}
+	}
+}
+
+func TestWriteToFile(t *testing.T) {
+	tests := []struct {
+		fileName string
+		format   rendering.ImageFormat
+	}{
+		{"render.ppm", rendering.PlainPPM},
+		{"render.PPM", rendering.PlainPPM},
+		{"render.png", rendering.PNG},
+	}
+	for _, test := range tests {
+		if format, err := rendering.FormatFromExtension(test.fileName); err != nil || format != test.format {
+			t.Errorf("Expected the format of %s = %v, but got %v (%v)", test.fileName, test.format, format, err)
+		}
+	}
+	if _, err := rendering.FormatFromExtension("render.jpg"); err == nil {
+		t.Errorf("Expected an error for an unsupported extension")
+	}
+
+	c := newEncodingTestCanvas()
+	dir := t.TempDir()
+
+	if err := c.WriteToFile(dir + "/render.ppm"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	loaded, err := rendering.LoadPPMFile(dir + "/render.ppm")
+	if err != nil || !loaded.PixelAt(4, 2).IsEqual(*color.NewColor(0, 0, 1)) {
+		t.Errorf("Expected to read back the ppm, but got %v", err)
+	}
+
+	if err := c.WriteToFile(dir + "/render.png"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	f, err := os.Open(dir + "/render.png")
+	if err != nil {
+		t.Fatalf("Expected the png to be written, but got %v", err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("Expected a valid png, but got %v", err)
+	}
+
+	if err := c.WriteToFile(dir + "/render.jpg"); err == nil {
+		t.Errorf("Expected an error for an unsupported extension")
+	}
+	if err := c.WriteToFile(dir + "/missing/render.ppm"); err == nil {
+		t.Errorf("Expected an error when the file can't be created")
+	}
+}
+
+/* ------------- Multiple lights --------------- */
+
+func TestDefaultWorldHasOneLight(t *testing.T) {
+	w := scene.DefaultWorld()
+	expected := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(-10, 10, -10))
+	if len(w.Lights) != 1 || *w.Lights[0].(*lighting.PointLight) != *expected {
+		t.Errorf("Expected w.lights = [%v], but got %v", *expected, w.Lights)
+	}
+}
+
+func TestShadeHit_SumsLights(t *testing.T) {
+	// the outer sphere of the default world, hit straight on
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, -5), Direction: *core.NewVector(0, 0, 1)}
+	shadeWith := func(lights ...lighting.Light) color.Color {
+		w := scene.DefaultWorld()
+		w.Lights = lights
+		i := rayt.NewIntersection(4, w.Objects[0])
+		comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+		return scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+	}
+
+	light := scene.DefaultWorld().Lights[0]
+	one := shadeWith(light)
+	two := shadeWith(light, light)
+
+	// the ambient term (surface color * ambient) is only counted once, while
+	// the direct lighting of both lights add up
+	ambient := *color.NewColor(0.8*0.1, 1.0*0.1, 0.6*0.1)
+	direct := color.SubtractColors([]color.Color{one, ambient})
+	expected := color.AddColors([]color.Color{ambient, *direct.ScalarMultiply(2)})
+	if !two.IsEqual(*expected) {
+		t.Errorf("Expected shade_hit with two lights = %v, but got %v", expected, two)
+	}
+
+	// without any light, there is no ambient light either
+	if none := shadeWith(); !none.IsEqual(*color.Black) {
+		t.Errorf("Expected shade_hit without lights = black, but got %v", none)
+	}
+}
+
+func TestAmbientIntensity(t *testing.T) {
+	w := scene.DefaultWorld()
+	w.Lights = []lighting.Light{
+		lighting.NewPointLight(*color.NewColor(1, 0.5, 0.2), *core.NewPoint(-10, 10, -10)),
+		lighting.NewPointLight(*color.NewColor(0.3, 0.9, 0.1), *core.NewPoint(10, 10, -10)),
+	}
+
+	// the brightest light, channel by channel
+	expected := color.NewColor(1, 0.9, 0.2)
+	if c := w.AmbientIntensity(w.Objects[0], *core.NewPoint(0, 0, -1)); !c.IsEqual(*expected) {
+		t.Errorf("Expected ambient intensity = %v, but got %v", expected, c)
+	}
+
+	// only the lights that light the object count
+	w.ObjectSets = map[string][]shape.Shape{"inner": {w.Objects[1]}}
+	w.LinkLight(w.Lights[0], "inner")
+	if c := w.AmbientIntensity(w.Objects[0], *core.NewPoint(0, 0, -1)); !c.IsEqual(*color.NewColor(0.3, 0.9, 0.1)) {
+		t.Errorf("Expected ambient intensity = the second light, but got %v", c)
+	}
+}
+
+func TestShadowsArePerLight(t *testing.T) {
+	// a point behind the default world's spheres, seen from the first light
+	// only; the second light is on the other side
+	w := scene.DefaultWorld()
+	blocked := w.Lights[0]
+	visible := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(10, -10, 10))
+	w.Lights = append(w.Lights, visible)
+
+	p := core.NewPoint(5, -5, 5)
+	if scene.IsShadowed(*w, blocked, *p) != 0 {
+		t.Errorf("Expected the point to be in the shadow of the first light")
+	}
+	if scene.IsShadowed(*w, visible, *p) != 1 {
+		t.Errorf("Expected the point to be lit by the second light")
+	}
+
+	// a floor below the spheres is in the shadow of the light above it, but
+	// still lit by a light off to the side
+	floor := shape.NewPlane()
+	floor.Transform = *core.TranslationM(0, -1, 0)
+	w = scene.DefaultWorld()
+	w.Objects = append(w.Objects, floor)
+	above := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, 0))
+	side := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(10, 1, 0))
+
+	r := rayt.Ray{Origin: *core.NewPoint(0.5, 5, 0), Direction: *core.NewVector(0, -1, 0)}
+	i := rayt.NewIntersection(6, floor)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+
+	w.Lights = []lighting.Light{above}
+	shadowed := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+	w.Lights = []lighting.Light{above, side}
+	lit := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+
+	ambient := color.NewColor(0.1, 0.1, 0.1)
+	if !shadowed.IsEqual(*ambient) {
+		t.Errorf("Expected the floor in shadow = %v, but got %v", ambient, shadowed)
+	}
+	if lit.R <= ambient.R {
+		t.Errorf("Expected the side light to light the floor, but got %v", lit)
+	}
+}
+
+/* ------------- Area lights --------------- */
+
+func TestPointLightIntensityAt(t *testing.T) {
+	// Scenario Outline: Point lights evaluate the light intensity at a given point
+	// Given w ← default_world()
+	// And light ← w.lights[0]
+	w := scene.DefaultWorld()
+	light := w.Lights[0]
+
+	// And pt ← <point>
+	// When intensity ← intensity_at(light, pt, w)
+	// Then intensity = <result>
+	tests := []struct {
+		point     *core.Point
+		intensity float64
+	}{
+		{core.NewPoint(0, 1.0001, 0), 1.0},
+		{core.NewPoint(-1.0001, 0, 0), 1.0},
+		{core.NewPoint(0, 0, -1.0001), 1.0},
+		{core.NewPoint(0, 0, 1.0001), 0.0},
+		{core.NewPoint(1.0001, 0, 0), 0.0},
+		{core.NewPoint(0, -1.0001, 0), 0.0},
+		{core.NewPoint(0, 0, 0), 0.0},
+	}
+	for _, test := range tests {
+		if intensity := scene.IsShadowed(*w, light, *test.point); intensity != test.intensity {
+			t.Errorf("Expected intensity_at(%v) = %v, but got %v", test.point, test.intensity, intensity)
+		}
+	}
+}
+
+func TestLighting_LightIntensity(t *testing.T) {
+	// Scenario Outline: lighting() uses light intensity to attenuate color
+	// Given w ← default_world()
+	// And w.light ← point_light(point(0, 0, -10), color(1, 1, 1))
+	// And shape ← the first object in w
+	// And shape.material.ambient ← 0.1
+	// And shape.material.diffuse ← 0.9
+	// And shape.material.specular ← 0
+	// And shape.material.color ← color(1, 1, 1)
+	w := scene.DefaultWorld()
+	light := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
+	s := w.Objects[0]
+	m := s.GetMaterial()
+	m.Ambient = 0.1
+	m.Diffuse = 0.9
+	m.Specular = 0
+	m.Color = *color.NewColor(1, 1, 1)
+
+	// And pt ← point(0, 0, -1)
+	// And eyev ← vector(0, 0, -1)
+	// And normalv ← vector(0, 0, -1)
+	pt := core.NewPoint(0, 0, -1)
+	eyev := core.NewVector(0, 0, -1)
+	normalv := core.NewVector(0, 0, -1)
+
+	// When result ← lighting(shape.material, shape, w.light, pt, eyev, normalv, <intensity>)
+	// Then result = <result>
+	tests := []struct {
+		intensity float64
+		expected  *color.Color
+	}{
+		{1.0, color.NewColor(1, 1, 1)},
+		{0.5, color.NewColor(0.55, 0.55, 0.55)},
+		{0.0, color.NewColor(0.1, 0.1, 0.1)},
+	}
+	for _, test := range tests {
+		result := lighting.Lighting(*m, s, light, *pt, *eyev, *normalv, test.intensity)
+		if !result.IsEqual(*test.expected) {
+			t.Errorf("Expected lighting with intensity %v = %v, but got %v", test.intensity, test.expected, result)
+		}
+	}
+}
+
+func TestCreatingAreaLight(t *testing.T) {
+	// Scenario: Creating an area light
+	// Given corner ← point(0, 0, 0)
+	// And v1 ← vector(2, 0, 0)
+	// And v2 ← vector(0, 0, 1)
+	// When light ← area_light(corner, v1, 4, v2, 2, color(1, 1, 1))
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 0), *core.NewVector(2, 0, 0), 4, *core.NewVector(0, 0, 1), 2)
+
+	// Then light.samples = 8
+	// And light.position = point(1, 0, 0.5)
+	if samples := light.SamplePoints(*core.NewPoint(0, 5, 0)); len(samples) != 8 {
+		t.Errorf("Expected 8 samples, but got %d", len(samples))
+	}
+	if !light.Position.IsEqual(*core.NewPoint(1, 0, 0.5)) {
+		t.Errorf("Expected light.position = (1, 0, 0.5), but got %v", light.Position)
+	}
+}
+
+func TestAreaLightSamplePoints(t *testing.T) {
+	// Scenario Outline: Finding a single point on an area light
+	// Given corner ← point(0, 0, 0)
+	// And v1 ← vector(2, 0, 0)
+	// And v2 ← vector(0, 0, 1)
+	// And light ← area_light(corner, v1, 4, v2, 2, color(1, 1, 1))
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 0), *core.NewVector(2, 0, 0), 4, *core.NewVector(0, 0, 1), 2)
+	light.Jitter = false
+	samples := light.SamplePoints(*core.NewPoint(0, 5, 0))
+
+	// When pt ← point_on_light(light, <u>, <v>)
+	// Then pt = <result>
+	tests := []struct {
+		u, v     int
+		expected *core.Point
+	}{
+		{0, 0, core.NewPoint(0.25, 0, 0.25)},
+		{1, 0, core.NewPoint(0.75, 0, 0.25)},
+		{0, 1, core.NewPoint(0.25, 0, 0.75)},
+		{2, 0, core.NewPoint(1.25, 0, 0.25)},
+		{3, 1, core.NewPoint(1.75, 0, 0.75)},
+	}
+	for _, test := range tests {
+		if pt := samples[test.v*4+test.u]; !pt.IsEqual(*test.expected) {
+			t.Errorf("Expected point_on_light(%d, %d) = %v, but got %v", test.u, test.v, test.expected, pt)
+		}
+	}
+}
+
+func TestAreaLightIntensityAt(t *testing.T) {
+	// Scenario Outline: The area light intensity function
+	// Given w ← default_world()
+	// And corner ← point(-0.5, -0.5, -5)
+	// And v1 ← vector(1, 0, 0)
+	// And v2 ← vector(0, 1, 0)
+	// And light ← area_light(corner, v1, 2, v2, 2, color(1, 1, 1))
+	w := scene.DefaultWorld()
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(-0.5, -0.5, -5), *core.NewVector(1, 0, 0), 2, *core.NewVector(0, 1, 0), 2)
+	light.Jitter = false
+
+	// And pt ← <point>
+	// When intensity ← intensity_at(light, pt, w)
+	// Then intensity = <result>
+	tests := []struct {
+		point     *core.Point
+		intensity float64
+	}{
+		{core.NewPoint(0, 0, 2), 0.0},
+		{core.NewPoint(1, -1, 2), 0.25},
+		{core.NewPoint(1.5, 0, 2), 0.5},
+		{core.NewPoint(1.25, 1.25, 3), 0.75},
+		{core.NewPoint(0, 0, -2), 1.0},
+	}
+	for _, test := range tests {
+		if intensity := scene.IsShadowed(*w, light, *test.point); !core.IsFloatEqual(intensity, test.intensity) {
+			t.Errorf("Expected intensity_at(%v) = %v, but got %v", test.point, test.intensity, intensity)
+		}
+	}
+}
+
+func TestLighting_SamplesAreaLight(t *testing.T) {
+	// Scenario Outline: lighting() samples the area light
+	// Given corner ← point(-0.5, -0.5, -5)
+	// And v1 ← vector(1, 0, 0)
+	// And v2 ← vector(0, 1, 0)
+	// And light ← area_light(corner, v1, 2, v2, 2, color(1, 1, 1))
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(-0.5, -0.5, -5), *core.NewVector(1, 0, 0), 2, *core.NewVector(0, 1, 0), 2)
+	light.Jitter = false
+
+	// And shape ← sphere()
+	// And shape.material.ambient ← 0.1
+	// And shape.material.diffuse ← 0.9
+	// And shape.material.specular ← 0
+	// And shape.material.color ← color(1, 1, 1)
+	s := shape.UnitSphere()
+	s.Material.Ambient = 0.1
+	s.Material.Diffuse = 0.9
+	s.Material.Specular = 0
+	s.Material.Color = *color.NewColor(1, 1, 1)
+
+	// And eye ← point(0, 0, -5)
+	eye := core.NewPoint(0, 0, -5)
+
+	tests := []struct {
+		point    *core.Point
+		expected *color.Color
+	}{
+		{core.NewPoint(0, 0, -1), color.NewColor(0.9965, 0.9965, 0.9965)},
+		{core.NewPoint(0, 0.7071, -0.7071), color.NewColor(0.62318, 0.62318, 0.62318)},
+	}
+	for _, test := range tests {
+		// And pt ← <point>
+		// And eyev ← normalize(eye - pt)
+		// And normalv ← vector(pt.x, pt.y, pt.z)
+		eyev := eye.Subtract(*test.point).Normalize()
+		normalv := core.NewVector(test.point.X, test.point.Y, test.point.Z)
+
+		// When result ← lighting(shape.material, shape, light, pt, eyev, normalv, 1.0)
+		result := lighting.Lighting(s.Material, s, light, *test.point, *eyev, *normalv, 1.0)
+
+		// Then result = <result>
+		if !result.IsEqual(*test.expected) {
+			t.Errorf("Expected lighting at %v = %v, but got %v", test.point, test.expected, result)
+		}
+	}
+}
+
+func TestJitteredAreaLightSamples(t *testing.T) {
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 0), *core.NewVector(2, 0, 0), 4, *core.NewVector(0, 0, 1), 2)
+	light.Seed = 3
+	p := core.NewPoint(0.3, 5, 0.1)
+
+	samples := light.SamplePoints(*p)
+	for i, sample := range samples {
+		// each sample stays within its own cell
+		u, v := i%4, i/4
+		if sample.X < float64(u)*0.5 || sample.X > float64(u+1)*0.5 || sample.Z < float64(v)*0.5 || sample.Z > float64(v+1)*0.5 || sample.Y != 0 {
+			t.Errorf("Expected sample %d within cell (%d, %d), but got %v", i, u, v, sample)
+		}
+	}
+
+	// the same point (and seed) gets the same samples, another point or seed
+	// different ones
+	again := light.SamplePoints(*p)
+	other := light.SamplePoints(*core.NewPoint(0.3, 5, 0.2))
+	light.Seed = 4
+	otherSeed := light.SamplePoints(*p)
+	for i := range samples {
+		if samples[i] != again[i] {
+			t.Fatalf("Expected the same samples for the same point")
+		}
+	}
+	if samples[0] == other[0] || samples[0] == otherSeed[0] {
+		t.Errorf("Expected different samples for another point or seed")
+	}
+}
+
+func TestDiskLightSamples(t *testing.T) {
+	center := core.NewPoint(0, 10, 0)
+	light := lighting.NewDiskLight(*color.NewColor(1, 1, 1), *center, *core.NewVector(2, 0, 0), *core.NewVector(0, 0, 2), 4, 4)
+	if light.Position != *center {
+		t.Errorf("Expected light.position = %v, but got %v", center, light.Position)
+	}
+
+	samples := light.SamplePoints(*core.NewPoint(0, 0, 0))
+	if len(samples) != 16 {
+		t.Fatalf("Expected 16 samples, but got %d", len(samples))
+	}
+	var sumX, sumZ float64
+	for _, sample := range samples {
+		// on the disk
+		v := sample.Subtract(*center)
+		if v.Magnitude() > 2+core.EPSILON || v.Y != 0 {
+			t.Errorf("Expected sample %v on the disk", sample)
+		}
+		sumX += v.X
+		sumZ += v.Z
+	}
+
+	// without jitter, the samples are spread evenly around the center
+	light.Jitter = false
+	sumX, sumZ = 0, 0
+	for _, sample := range light.SamplePoints(*core.NewPoint(0, 0, 0)) {
+		sumX += sample.X
+		sumZ += sample.Z
+	}
+	if !core.IsFloatEqual(sumX, 0) || !core.IsFloatEqual(sumZ, 0) {
+		t.Errorf("Expected the samples to be centered on the light, but got an offset of (%v, %v)", sumX/16, sumZ/16)
+	}
+}
+
+// Light that counts how many times it is sampled
+type countingLight struct {
+	lighting.Light
+	calls int
+}
+
+func (l *countingLight) SamplesAt(point core.Point) []lighting.LightSample {
+	l.calls++
+	return l.Light.SamplesAt(point)
+}
+
+func TestShadeHit_SamplesLightOnce(t *testing.T) {
+	w := scene.DefaultWorld()
+	light := &countingLight{Light: lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(-10, 10, -10), *core.NewVector(1, 0, 0), 4, *core.NewVector(0, 1, 0), 4)}
+	w.Lights = []lighting.Light{light}
+
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, -5), Direction: *core.NewVector(0, 0, 1)}
+	i := rayt.NewIntersection(4, w.Objects[0])
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+	scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+
+	// the same samples are used for the shadow and the lighting
+	if light.calls != 1 {
+		t.Errorf("Expected the light to be sampled once, but it was sampled %d times", light.calls)
+	}
+}
+
+func TestAreaLightSoftShadow(t *testing.T) {
+	// a unit sphere above a floor, lit from above by a large area light: the
+	// shadow fades from the umbra, right below the sphere, to fully lit
+	floor := shape.NewPlane()
+	floor.Transform = *core.TranslationM(0, -2, 0)
+	w := &scene.World{Objects: []shape.Shape{shape.UnitSphere(), floor}}
+	light := lighting.NewAreaLight(*color.NewColor(1, 1, 1), *core.NewPoint(-2, 5, -2), *core.NewVector(4, 0, 0), 8, *core.NewVector(0, 0, 4), 8)
+	light.Seed = 11
+
+	previous := 0.0
+	for x := 0.0; x <= 4; x += 0.25 {
+		intensity := scene.IsShadowed(*w, light, *core.NewPoint(x, -2, 0))
+		if intensity < previous-0.1 {
+			t.Errorf("Expected the shadow to fade away from the sphere, but got an intensity of %v after %v at x = %v", intensity, previous, x)
+		}
+		previous = intensity
+	}
+
+	if umbra := scene.IsShadowed(*w, light, *core.NewPoint(0, -2, 0)); umbra != 0 {
+		t.Errorf("Expected the point right below the sphere in full shadow, but got %v", umbra)
+	}
+	if penumbra := scene.IsShadowed(*w, light, *core.NewPoint(1.5, -2, 0)); penumbra <= 0 || penumbra >= 1 {
+		t.Errorf("Expected a point in the penumbra to be partially shadowed, but got %v", penumbra)
+	}
+	if lit := scene.IsShadowed(*w, light, *core.NewPoint(4, -2, 0)); lit != 1 {
+		t.Errorf("Expected a point far from the sphere to be fully lit, but got %v", lit)
+	}
+}
+
+/* ------------- Directional and spot lights --------------- */
+
+func TestDirectionalLightSamples(t *testing.T) {
+	light := lighting.NewDirectionalLight(*color.NewColor(1, 1, 1), *core.NewVector(0, -2, 0))
+
+	// every point is lit from the same direction, infinitely far away
+	for _, p := range []*core.Point{core.NewPoint(0, 0, 0), core.NewPoint(100, -50, 3)} {
+		samples := light.SamplesAt(*p)
+		if len(samples) != 1 {
+			t.Fatalf("Expected a single sample, but got %d", len(samples))
+		}
+		if !samples[0].Direction.IsEqual(*core.NewVector(0, 1, 0)) {
+			t.Errorf("Expected the light to come from (0, 1, 0) at %v, but got %v", p, samples[0].Direction)
+		}
+		if !math.IsInf(samples[0].Distance, 1) {
+			t.Errorf("Expected the light to be infinitely far away, but got %v", samples[0].Distance)
+		}
+	}
+}
+
+func TestLighting_DirectionalLight(t *testing.T) {
+	// same as lighting with the eye between the light and the surface, with
+	// a sun shining straight at the surface instead of a point light
+	m := material.DefaultMaterial()
+	position := core.NewPoint(0, 0, 0)
+	eyev := core.NewVector(0, 0, -1)
+	normalv := core.NewVector(0, 0, -1)
+	light := lighting.NewDirectionalLight(*color.NewColor(1, 1, 1), *core.NewVector(0, 0, 1))
+
+	result := lighting.Lighting(m, nil, light, *position, *eyev, *normalv, 1)
+	if expected := color.NewColor(1.9, 1.9, 1.9); !result.IsEqual(*expected) {
+		t.Errorf("Expected lighting = %v, but got %v", expected, result)
+	}
+}
+
+func TestDirectionalLightShadows(t *testing.T) {
+	// a sun right above the default world
+	w := scene.DefaultWorld()
+	light := lighting.NewDirectionalLight(*color.NewColor(1, 1, 1), *core.NewVector(0, -1, 0))
+
+	tests := []struct {
+		point     *core.Point
+		intensity float64
+	}{
+		{core.NewPoint(0, -5, 0), 0},
+		// shadow rays are parallel, so the shadow doesn't shrink (or grow)
+		// with the distance to the spheres
+		{core.NewPoint(0.9, -1000, 0), 0},
+		{core.NewPoint(1.1, -1000, 0), 1},
+		{core.NewPoint(5, -5, 0), 1},
+		{core.NewPoint(0, 2, 0), 1},
+	}
+	for _, test := range tests {
+		if intensity := scene.IsShadowed(*w, light, *test.point); intensity != test.intensity {
+			t.Errorf("Expected is_shadowed(%v) = %v, but got %v", test.point, test.intensity, intensity)
+		}
+	}
+}
+
+func TestSpotLightCone(t *testing.T) {
+	// a spot above the origin pointing down, with a cone of 30 degrees
+	light := lighting.NewSpotLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, 0), *core.NewVector(0, -1, 0), math.Pi/6, 0)
+
+	tests := []struct {
+		point     *core.Point
+		intensity *color.Color
+	}{
+		{core.NewPoint(0, 0, 0), color.NewColor(1, 1, 1)},
+		{core.NewPoint(5, 0, 0), color.NewColor(1, 1, 1)},
+		{core.NewPoint(0, 0, -6), color.NewColor(0, 0, 0)},
+		{core.NewPoint(10, 0, 0), color.NewColor(0, 0, 0)},
+		// behind the spot
+		{core.NewPoint(0, 20, 0), color.NewColor(0, 0, 0)},
+	}
+	for _, test := range tests {
+		samples := light.SamplesAt(*test.point)
+		if len(samples) != 1 || !samples[0].Intensity.IsEqual(*test.intensity) {
+			t.Errorf("Expected the spot intensity at %v = %v, but got %v", test.point, test.intensity, samples)
+		}
+	}
+
+	sample := light.SamplesAt(*core.NewPoint(0, 0, 0))[0]
+	if !sample.Direction.IsEqual(*core.NewVector(0, 1, 0)) || !core.IsFloatEqual(sample.Distance, 10) {
+		t.Errorf("Expected the spot 10 units away along (0, 1, 0), but got %v", sample)
+	}
+}
+
+func TestSpotLightFalloff(t *testing.T) {
+	// full intensity up to 30 degrees off the axis, fading out until 45
+	light := lighting.NewSpotLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, 0), *core.NewVector(0, 0, 1), math.Pi/4, math.Pi/12)
+	intensityAt := func(angle float64) float64 {
+		p := core.NewPoint(math.Sin(angle), 0, math.Cos(angle))
+		return light.SamplesAt(*p)[0].Intensity.R
+	}
+
+	if i := intensityAt(math.Pi / 6); !core.IsFloatEqual(i, 1) {
+		t.Errorf("Expected full intensity inside the cone, but got %v", i)
+	}
+	if i := intensityAt(math.Pi/4 + 0.01); i != 0 {
+		t.Errorf("Expected no light outside the cone, but got %v", i)
+	}
+
+	previous := 1.0
+	for angle := math.Pi / 6; angle <= math.Pi/4; angle += 0.02 {
+		i := intensityAt(angle)
+		if i > previous {
+			t.Errorf("Expected the spot to fade out towards the edge, but got %v after %v", i, previous)
+		}
+		previous = i
+	}
+	if i := intensityAt(0.68); i <= 0 || i >= 1 {
+		t.Errorf("Expected a partial intensity within the falloff, but got %v", i)
+	}
+}
+
+func TestLighting_SpotLight(t *testing.T) {
+	m := material.DefaultMaterial()
+	eyev := core.NewVector(0, 0, -1)
+	normalv := core.NewVector(0, 0, -1)
+	position := core.NewPoint(0, 0, 0)
+
+	// pointing at the surface, a spot lights it like a point light
+	spot := lighting.NewSpotLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10), *core.NewVector(0, 0, 1), math.Pi/8, 0)
+	point := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))
+	result := lighting.Lighting(m, nil, spot, *position, *eyev, *normalv, 1)
+	if expected := lighting.Lighting(m, nil, point, *position, *eyev, *normalv, 1); !result.IsEqual(expected) {
+		t.Errorf("Expected lighting = %v, but got %v", expected, result)
+	}
+
+	// pointing away, the spot doesn't light the point at all, not even its
+	// ambient term
+	spot.Direction = *core.NewVector(1, 0, 0)
+	result = lighting.Lighting(m, nil, spot, *position, *eyev, *normalv, 1)
+	if expected := color.Black; !result.IsEqual(*expected) {
+		t.Errorf("Expected lighting = %v, but got %v", expected, result)
+	}
+}
+
+func TestShadeHit_OutsideSpotLightCone(t *testing.T) {
+	// the outer sphere of the default world, hit straight on
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, -5), Direction: *core.NewVector(0, 0, 1)}
+	shade := func(w *scene.World) color.Color {
+		i := rayt.NewIntersection(4, w.Objects[0])
+		comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+		return scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+	}
+
+	// a spot right where the default light is, aimed away from the spheres,
+	// doesn't brighten them at all
+	w := scene.DefaultWorld()
+	spot := lighting.NewSpotLight(*color.NewColor(1, 1, 1), *core.NewPoint(-10, 10, -10), *core.NewVector(-1, 0, 0), math.Pi/8, 0)
+	w.Lights = []lighting.Light{spot}
+	if c := shade(w); !c.IsEqual(*color.Black) {
+		t.Errorf("Expected a point outside the cone = black, but got %v", c)
+	}
+	if c := w.AmbientIntensity(w.Objects[0], *core.NewPoint(0, 0, -1)); !c.IsEqual(*color.Black) {
+		t.Errorf("Expected no ambient light outside the cone, but got %v", c)
+	}
+
+	// nor does it add to the ambient light of another light
+	w.Lights = []lighting.Light{lighting.NewPointLight(*color.NewColor(0.5, 0.5, 0.5), *core.NewPoint(-10, 10, -10))}
+	expected := shade(w)
+	spot.Intensity = *color.NewColor(2, 2, 2)
+	w.Lights = append(w.Lights, spot)
+	if c := shade(w); !c.IsEqual(expected) {
+		t.Errorf("Expected a spot pointing away to leave the color = %v, but got %v", expected, c)
+	}
+}
+
+func TestSpotLightShadows(t *testing.T) {
+	// the spheres of the default world are between the spot and the point
+	w := scene.DefaultWorld()
+	light := lighting.NewSpotLight(*color.NewColor(1, 1, 1), *core.NewPoint(-10, 10, -10), *core.NewVector(1, -1, 1), math.Pi/4, 0)
+
+	if intensity := scene.IsShadowed(*w, light, *core.NewPoint(10, -10, 10)); intensity != 0 {
+		t.Errorf("Expected the point to be in shadow, but got %v", intensity)
+	}
+	// an object behind the point doesn't cast a shadow on it
+	if intensity := scene.IsShadowed(*w, light, *core.NewPoint(-2, 2, -2)); intensity != 1 {
+		t.Errorf("Expected the point not to be in shadow, but got %v", intensity)
+	}
+}
+
+/* ------------- Light attenuation and physical units --------------- */
+
+func TestAttenuation(t *testing.T) {
+	tests := []struct {
+		attenuation lighting.Attenuation
+		distance    float64
+		expected    float64
+	}{
+		{lighting.Attenuation{}, 10, 1},
+		{lighting.NoAttenuation, 10, 1},
+		{lighting.LinearAttenuation, 2, 0.5},
+		{lighting.InverseSquareAttenuation, 2, 0.25},
+		{lighting.InverseSquareAttenuation, 0.5, 4},
+		{lighting.Attenuation{Constant: 1, Linear: 0.09, Quadratic: 0.032}, 10, 1 / 5.1},
+		// right at the light
+		{lighting.InverseSquareAttenuation, 0, 1},
+	}
+	for _, test := range tests {
+		if factor := test.attenuation.At(test.distance); !core.IsFloatEqual(factor, test.expected) {
+			t.Errorf("Expected %v at distance %v = %v, but got %v", test.attenuation, test.distance, test.expected, factor)
+		}
+	}
+}
+
+func TestLightsAttenuateWithDistance(t *testing.T) {
+	white := *color.NewColor(1, 1, 1)
+	m := material.DefaultMaterial()
+	m.Ambient = 0
+	m.Specular = 0
+	eyev := core.NewVector(0, 0, -1)
+	normalv := core.NewVector(0, 0, -1)
+	lightingAt := func(light lighting.Light, distance float64) color.Color {
+		// the light is at the origin, the surface distance away facing it
+		return lighting.Lighting(m, nil, light, *core.NewPoint(0, 0, distance), *eyev, *normalv, 1)
+	}
+
+	// without attenuation, the distance doesn't matter
+	point := lighting.NewPointLight(white, *core.NewPoint(0, 0, 0))
+	if near, far := lightingAt(point, 1), lightingAt(point, 1000); !near.IsEqual(far) {
+		t.Errorf("Expected the same lighting near and far without attenuation, but got %v and %v", near, far)
+	}
+
+	point.Attenuation = lighting.InverseSquareAttenuation
+	if c := lightingAt(point, 3); !c.IsEqual(*color.NewColor(0.1, 0.1, 0.1)) {
+		t.Errorf("Expected lighting 3 units away = 0.9/9, but got %v", c)
+	}
+
+	spot := lighting.NewSpotLight(white, *core.NewPoint(0, 0, 0), *core.NewVector(0, 0, 1), math.Pi/4, 0)
+	spot.Attenuation = lighting.LinearAttenuation
+	if c := lightingAt(spot, 2); !c.IsEqual(*color.NewColor(0.45, 0.45, 0.45)) {
+		t.Errorf("Expected spot lighting 2 units away = 0.9/2, but got %v", c)
+	}
+
+	// every sample of an area light fades with its own distance
+	area := lighting.NewAreaLight(white, *core.NewPoint(-1, 0, 0), *core.NewVector(2, 0, 0), 2, *core.NewVector(0, 0.0001, 0), 1)
+	area.Jitter = false
+	area.Attenuation = lighting.InverseSquareAttenuation
+	for _, sample := range area.SamplesAt(*core.NewPoint(0, 0, 1)) {
+		// the samples are at x = ±0.5
+		if !sample.Intensity.IsEqual(*color.NewColor(0.8, 0.8, 0.8)) {
+			t.Errorf("Expected the sample intensity = 1/1.25, but got %v", sample.Intensity)
+		}
+	}
+
+	// the sun is too far away to fade
+	sun := lighting.NewDirectionalLight(white, *core.NewVector(0, 0, 1))
+	if near, far := lightingAt(sun, 1), lightingAt(sun, 1000); !near.IsEqual(far) {
+		t.Errorf("Expected the same sunlight near and far, but got %v and %v", near, far)
+	}
+}
+
+func TestAmbientLightAttenuates(t *testing.T) {
+	// a default sphere (with its ambient term) lit by a light of intensity d²
+	// d units away, which fades with the square of the distance: the light
+	// reaching the sphere is about 1, whatever the distance
+	for _, d := range []float64{10, 100} {
+		w := &scene.World{Objects: []shape.Shape{shape.UnitSphere()}}
+		light := lighting.NewPointLight(*color.NewColor(d*d, d*d, d*d), *core.NewPoint(0, 0, -d))
+		light.Attenuation = lighting.InverseSquareAttenuation
+		w.Lights = []lighting.Light{light}
+
+		// the back of the sphere is only lit by the ambient light, i.e.
+		// ambient * the light reaching it from d + 1 away
+		r := rayt.Ray{Origin: *core.NewPoint(0, 0, 5), Direction: *core.NewVector(0, 0, -1)}
+		i := rayt.NewIntersection(4, w.Objects[0])
+		comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+		back := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+		ambient := 0.1 * d * d / ((d + 1) * (d + 1))
+		if !back.IsEqual(*color.NewColor(ambient, ambient, ambient)) {
+			t.Errorf("d = %v: Expected the back of the sphere = %v, but got %v", d, ambient, back)
+		}
+
+		// and so is it in lighting()
+		m := material.DefaultMaterial()
+		c := lighting.Lighting(m, nil, light, *core.NewPoint(0, 0, 1), *core.NewVector(0, 0, 1), *core.NewVector(0, 0, 1), 0)
+		if !c.IsEqual(back) {
+			t.Errorf("d = %v: Expected lighting of the back = %v, but got %v", d, back, c)
+		}
+	}
+}
+
+func TestColorFromKelvin(t *testing.T) {
+	if c := color.FromKelvin(6600); !c.IsEqual(*color.NewColor(1, 1, 1)) {
+		t.Errorf("Expected 6600K = white, but got %v", c)
+	}
+
+	// warm lights are orange
+	warm := color.FromKelvin(2700)
+	if warm.R != 1 || warm.G >= 1 || warm.B >= warm.G {
+		t.Errorf("Expected 2700K to be orange, but got %v", warm)
+	}
+
+	// cool lights are blue
+	cool := color.FromKelvin(10000)
+	if cool.B != 1 || cool.R >= 1 || cool.R >= cool.G {
+		t.Errorf("Expected 10000K to be blue, but got %v", cool)
+	}
+
+	// the reddest (and bluest) color is the one at the end of the range
+	if c := color.FromKelvin(500); !c.IsEqual(*color.FromKelvin(1000)) {
+		t.Errorf("Expected 500K = 1000K, but got %v", c)
+	}
+	if c := color.FromKelvin(100000); !c.IsEqual(*color.FromKelvin(40000)) {
+		t.Errorf("Expected 100000K = 40000K, but got %v", c)
+	}
+}
+
+func TestPhysicalLightUnits(t *testing.T) {
+	if lumens := lighting.WattsToLumens(60, lighting.IncandescentEfficacy); lumens != 900 {
+		t.Errorf("Expected a 60W bulb to emit 900 lumens, but got %v", lumens)
+	}
+
+	// 4π lumens spread over the whole sphere is 1 candela
+	if i := lighting.PointIntensity(4*math.Pi*100, 100); !core.IsFloatEqual(i, 1) {
+		t.Errorf("Expected point intensity = 1, but got %v", i)
+	}
+	// a cone of 90 degrees is a hemisphere, so a spot concentrates its light
+	// twice as much as a point light
+	if i := lighting.SpotIntensity(4*math.Pi, math.Pi/2, 1); !core.IsFloatEqual(i, 2) {
+		t.Errorf("Expected spot intensity = 2, but got %v", i)
+	}
+	if i := lighting.DirectionalIntensity(100000, 100000); i != 1 {
+		t.Errorf("Expected directional intensity = 1, but got %v", i)
+	}
+
+	// an 800 lumens bulb of 2700K, 2 meters above a white floor, for an
+	// exposure of 100 lux
+	m := material.DefaultMaterial()
+	m.Ambient = 0
+	m.Diffuse = 1
+	m.Specular = 0
+	intensity := lighting.PointIntensity(800, 100)
+	light := lighting.NewPointLight(*color.FromKelvin(2700).ScalarMultiply(intensity), *core.NewPoint(0, 2, 0))
+	light.Attenuation = lighting.InverseSquareAttenuation
+
+	result := lighting.Lighting(m, nil, light, *core.NewPoint(0, 0, 0), *core.NewVector(0, 1, 0), *core.NewVector(0, 1, 0), 1)
+	// 800 / 4π candela, over 2² meters: about 16 lux
+	expected := color.FromKelvin(2700).ScalarMultiply(800 / (4 * math.Pi) / 4 / 100)
+	if !result.IsEqual(*expected) {
+		t.Errorf("Expected lighting = %v, but got %v", expected, result)
+	}
+}
+
+/* ------------- Shadow flags and light linking --------------- */
+
+func TestShadowFlagsDefault(t *testing.T) {
+	for _, s := range scene.DefaultWorld().Objects {
+		if !s.CastsShadow() || !s.ReceivesShadow() {
+			t.Errorf("Expected the objects of the default world to cast and receive shadows")
+		}
+	}
+
+	// a sphere above a floor, both with materials built without
+	// DefaultMaterial
+	ball := shape.UnitSphere()
+	ball.Material = material.Material{Color: *color.NewColor(1, 0, 0), Diffuse: 0.9}
+	floor := shape.NewPlane()
+	floor.Transform = *core.TranslationM(0, -2, 0)
+	floor.Material = material.Material{Color: *color.NewColor(1, 1, 1), Diffuse: 0.9}
+	w := &scene.World{
+		Lights:  []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 10, 0))},
+		Objects: []shape.Shape{ball, floor},
+	}
+	if intensity := scene.IsShadowed(*w, w.Lights[0], *core.NewPoint(0, -2, 0)); intensity != 0 {
+		t.Errorf("Expected the sphere to cast a shadow on the floor, but got %v", intensity)
+	}
+}
+
+func TestShadowFlagsOfGroups(t *testing.T) {
+	s := shape.UnitSphere()
+	inner := shape.NewGroup()
+	inner.AddChild(s)
+	outer := shape.NewGroup()
+	outer.AddChild(inner)
+
+	outer.NoCastShadow = true
+	if s.CastsShadow() {
+		t.Errorf("Expected the children of a group without shadows not to cast shadows")
+	}
+	if !s.ReceivesShadow() {
+		t.Errorf("Expected the children of the group to still receive shadows")
+	}
+
+	inner.NoReceiveShadow = true
+	if s.ReceivesShadow() {
+		t.Errorf("Expected the children of a group not receiving shadows not to receive shadows")
+	}
+}
+
+func TestIsShadowedSkipsNonCastingObjects(t *testing.T) {
+	// the default world's spheres are between the light and the point
+	w := scene.DefaultWorld()
+	p := core.NewPoint(10, -10, 10)
+
+	// the inner sphere is still in the way
+	w.Objects[0].(*shape.Sphere).NoCastShadow = true
+	if intensity := scene.IsShadowed(*w, w.Lights[0], *p); intensity != 0 {
+		t.Errorf("Expected the inner sphere to cast a shadow, but got %v", intensity)
+	}
+
+	w.Objects[1].(*shape.Sphere).NoCastShadow = true
+	if intensity := scene.IsShadowed(*w, w.Lights[0], *p); intensity != 1 {
+		t.Errorf("Expected no shadow without casting objects, but got %v", intensity)
+	}
+}
+
+func TestShadeHit_ObjectNotReceivingShadows(t *testing.T) {
+	// Same as shade_hit() is given an intersection in shadow, except that s2
+	// ignores the shadow of s1
+	w := &scene.World{}
+	w.Lights = []lighting.Light{lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(0, 0, -10))}
+	s1 := shape.UnitSphere()
+	s2 := shape.UnitSphere()
+	s2.Transform = *core.TranslationM(0, 0, 10)
+	s2.NoReceiveShadow = true
+	w.Objects = []shape.Shape{s1, s2}
+
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, 5), Direction: *core.NewVector(0, 0, 1)}
+	i := rayt.NewIntersection(4, s2)
+	comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+	c := scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+
+	// lit as if s1 wasn't there: the eye is between the light and the surface
+	if expected := color.NewColor(1.9, 1.9, 1.9); !c.IsEqual(*expected) {
+		t.Errorf("Expected shade_hit = %v, but got %v", expected, c)
+	}
+
+	// s1 still casts its shadow on the objects receiving shadows
+	if intensity := scene.IsShadowed(*w, w.Lights[0], comps.OverPoint); intensity != 0 {
+		t.Errorf("Expected s1 to still cast a shadow, but got %v", intensity)
+	}
+}
+
+func TestLightLinking(t *testing.T) {
+	w := scene.DefaultWorld()
+	outer, inner := w.Objects[0], w.Objects[1]
+	light := w.Lights[0]
+	other := lighting.NewPointLight(*color.NewColor(1, 1, 1), *core.NewPoint(10, 10, -10))
+	w.Lights = append(w.Lights, other)
+
+	// lights without links light everything
+	if !w.Illuminates(light, outer) || !w.Illuminates(light, inner) {
+		t.Errorf("Expected a light without links to light every object")
+	}
+
+	w.ObjectSets = map[string][]shape.Shape{"inner": {inner}}
+	w.LinkLight(light, "inner")
+	if w.Illuminates(light, outer) || !w.Illuminates(light, inner) {
+		t.Errorf("Expected the linked light to only light the inner sphere")
+	}
+	if !w.Illuminates(other, outer) {
+		t.Errorf("Expected the other light to still light every object")
+	}
+
+	// linking a group links all its children
+	g := shape.NewGroup()
+	s := shape.UnitSphere()
+	g.AddChild(s)
+	w.ObjectSets["inner"] = append(w.ObjectSets["inner"], g)
+	if !w.Illuminates(light, s) {
+		t.Errorf("Expected the linked light to light the children of a linked group")
+	}
+
+	// links to a missing set don't light anything
+	w.LinkLight(other, "missing")
+	if w.Illuminates(other, outer) {
+		t.Errorf("Expected a light linked to a missing set to light nothing")
+	}
+}
+
+func TestShadeHit_LightLinking(t *testing.T) {
+	// the outer sphere of the default world, hit straight on
+	r := rayt.Ray{Origin: *core.NewPoint(0, 0, -5), Direction: *core.NewVector(0, 0, 1)}
+	shade := func(w *scene.World) color.Color {
+		i := rayt.NewIntersection(4, w.Objects[0])
+		comps := scene.PrepareComputations(i, r, []rayt.Intersection{i})
+		return scene.ShadeHit(*w, *comps, scene.MaxReflectionDepth)
+	}
+
+	w := scene.DefaultWorld()
+	lit := shade(w)
+
+	w.ObjectSets = map[string][]shape.Shape{"outer": {w.Objects[0]}}
+	w.LinkLight(w.Lights[0], "outer")
+	if c := shade(w); !c.IsEqual(lit) {
+		t.Errorf("Expected the sphere lit by its linked light = %v, but got %v", lit, c)
+	}
+
+	// linked to another object, the light doesn't light the sphere at all,
+	// not even its ambient term
+	w.ObjectSets["outer"] = []shape.Shape{w.Objects[1]}
+	if c := shade(w); !c.IsEqual(*color.Black) {
+		t.Errorf("Expected the sphere not lit by the light = black, but got %v", c)
+	}
+
+	// while an unlinked light still lights it, ambient term included
+	fill := lighting.NewPointLight(*color.NewColor(0.5, 0.5, 0.5), *core.NewPoint(-10, 10, -10))
+	w.Lights = append(w.Lights, fill)
+	if c := shade(w); !c.IsEqual(*lit.ScalarMultiply(0.5)) {
+		t.Errorf("Expected the sphere lit by the fill light = %v, but got %v", lit.ScalarMultiply(0.5), c)
 	}
 }
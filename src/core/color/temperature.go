@@ -0,0 +1,38 @@
+package color
+
+import (
+	"math"
+)
+
+/*
+FromKelvin is the color of a black body at the given temperature (in kelvin),
+e.g. 2700 for a warm incandescent bulb, 5500 for daylight or 10000 for a blue
+sky. 6600K is white; lower temperatures are redder and higher ones bluer.
+
+It uses Tanner Helland's fit of the black body colors, which is good enough
+for lights between 1000K and 40000K (temperatures outside that range are
+clamped to it).
+*/
+func FromKelvin(kelvin float64) *Color {
+	t := math.Max(1000, math.Min(kelvin, 40000)) / 100
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	return NewColor(r/255, g/255, b/255).Clamp()
+}
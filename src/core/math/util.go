@@ -16,7 +16,7 @@ func Clamp(f float64) float64 {
 	}
 
 	if f < 0 {
-		return 1
+		return 0
 	}
 
 	return f
@@ -0,0 +1,171 @@
+package lighting
+
+import (
+	"math"
+	"math/rand/v2"
+
+	color "github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Shape of the surface of an area light
+type AreaKind int
+
+const (
+	// parallelogram spanned by UVec and VVec from Corner
+	RectArea AreaKind = iota
+	// ellipse (a disk when UVec and VVec have the same length) centered on
+	// Position, with UVec and VVec as radii
+	DiskArea
+)
+
+/*
+AreaLight emits light from a whole surface, which casts soft shadows: a point
+that only sees part of the light is in the penumbra.
+
+Area lights are sampled: their surface is divided into USteps x VSteps cells,
+and a point in each cell stands for the light of that cell. More cells give
+smoother penumbras, but every cell costs a shadow ray.
+*/
+type AreaLight struct {
+	Intensity color.Color
+	// center of the light
+	Position core.Point
+	Kind     AreaKind
+
+	Corner core.Point
+	UVec   core.Vector
+	VVec   core.Vector
+	USteps int
+	VSteps int
+	// when set, each cell is sampled at a random point instead of its center,
+	// which trades the banding of the penumbras for noise. The random points
+	// only depend on Seed and on the point being lit, so renders are
+	// reproducible (even in parallel)
+	Jitter bool
+	Seed   uint64
+	// applied to the distance to each sample
+	Attenuation Attenuation
+}
+
+// Rectangular (more precisely parallelogram) light with one corner at corner
+// and the edges fullUVec and fullVVec, sampled with usteps x vsteps jittered
+// cells
+func NewAreaLight(intensity color.Color, corner core.Point, fullUVec core.Vector, usteps int, fullVVec core.Vector, vsteps int) *AreaLight {
+	center := corner.AddVector(*fullUVec.ScalarMultiply(0.5)).AddVector(*fullVVec.ScalarMultiply(0.5))
+	return &AreaLight{
+		Intensity: intensity,
+		Position:  *center,
+		Kind:      RectArea,
+		Corner:    corner,
+		UVec:      fullUVec,
+		VVec:      fullVVec,
+		USteps:    usteps,
+		VSteps:    vsteps,
+		Jitter:    true,
+	}
+}
+
+// Disk light centered on center, in the plane spanned by the radii uRadius and
+// vRadius, sampled with usteps x vsteps jittered cells
+func NewDiskLight(intensity color.Color, center core.Point, uRadius core.Vector, vRadius core.Vector, usteps int, vsteps int) *AreaLight {
+	return &AreaLight{
+		Intensity: intensity,
+		Position:  center,
+		Kind:      DiskArea,
+		UVec:      uRadius,
+		VVec:      vRadius,
+		USteps:    usteps,
+		VSteps:    vsteps,
+		Jitter:    true,
+	}
+}
+
+func (l *AreaLight) GetIntensity() color.Color {
+	return l.Intensity
+}
+
+// One sample per cell of the light, see SamplePoints
+func (l *AreaLight) SamplesAt(point core.Point) []LightSample {
+	points := l.SamplePoints(point)
+	samples := make([]LightSample, len(points))
+	for i, p := range points {
+		samples[i] = sampleTowards(point, p, l.Intensity, l.Attenuation)
+	}
+	return samples
+}
+
+/*
+SamplePoints are the points of the light that stand for the whole light when
+lighting (or checking the shadow of) the given point: a point in each cell of
+the light.
+
+The same point always gets the same samples, so that the shadow and the
+shading of a point agree with each other.
+*/
+func (l *AreaLight) SamplePoints(point core.Point) []core.Point {
+	if l.USteps < 1 || l.VSteps < 1 {
+		return []core.Point{l.Position}
+	}
+
+	var rng *rand.Rand
+	if l.Jitter {
+		rng = rand.New(rand.NewPCG(l.Seed, pointHash(point)))
+	}
+
+	samples := make([]core.Point, 0, l.USteps*l.VSteps)
+	for v := 0; v < l.VSteps; v++ {
+		for u := 0; u < l.USteps; u++ {
+			// position of the sample within the cell
+			ju, jv := 0.5, 0.5
+			if rng != nil {
+				ju, jv = rng.Float64(), rng.Float64()
+			}
+			s := (float64(u) + ju) / float64(l.USteps)
+			t := (float64(v) + jv) / float64(l.VSteps)
+			samples = append(samples, l.pointAt(s, t))
+		}
+	}
+
+	return samples
+}
+
+// Point of the light at (s, t), both in [0, 1)
+func (l *AreaLight) pointAt(s float64, t float64) core.Point {
+	if l.Kind == DiskArea {
+		a, b := concentricDisk(s, t)
+		return *l.Position.AddVector(*l.UVec.ScalarMultiply(a)).AddVector(*l.VVec.ScalarMultiply(b))
+	}
+	return *l.Corner.AddVector(*l.UVec.ScalarMultiply(s)).AddVector(*l.VVec.ScalarMultiply(t))
+}
+
+// Shirley's concentric mapping of the unit square onto the unit disk, which
+// keeps cells of the square about the same size (and shape) on the disk
+func concentricDisk(s float64, t float64) (float64, float64) {
+	// map to [-1, 1]
+	a := 2*s - 1
+	b := 2*t - 1
+	if a == 0 && b == 0 {
+		return 0, 0
+	}
+
+	var r, phi float64
+	if math.Abs(a) > math.Abs(b) {
+		r = a
+		phi = (math.Pi / 4) * (b / a)
+	} else {
+		r = b
+		phi = math.Pi/2 - (math.Pi/4)*(a/b)
+	}
+	return r * math.Cos(phi), r * math.Sin(phi)
+}
+
+// Hash of the coordinates of a point, to seed the jitter of the samples
+func pointHash(p core.Point) uint64 {
+	h := uint64(14695981039346656037)
+	for _, v := range []float64{p.X, p.Y, p.Z} {
+		h ^= math.Float64bits(v)
+		h *= 1099511628211
+	}
+	return h
+}
@@ -0,0 +1,37 @@
+package lighting
+
+import (
+	"math"
+
+	color "github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+/*
+DirectionalLight is a light so far away (like the sun) that all its rays are
+parallel: it lights every point of the world from the same direction, with the
+same intensity (it doesn't fade with the distance), and its shadows are cast
+along parallel shadow rays.
+*/
+type DirectionalLight struct {
+	Intensity color.Color
+	// direction in which the light travels, e.g. (0, -1, 0) for a sun right
+	// above the scene
+	Direction core.Vector
+}
+
+func NewDirectionalLight(intensity color.Color, direction core.Vector) *DirectionalLight {
+	return &DirectionalLight{Intensity: intensity, Direction: *direction.Normalize()}
+}
+
+func (l *DirectionalLight) GetIntensity() color.Color {
+	return l.Intensity
+}
+
+func (l *DirectionalLight) SamplesAt(point core.Point) []LightSample {
+	return []LightSample{{
+		Direction: *l.Direction.Normalize().Negate(),
+		Distance:  math.Inf(1),
+		Intensity: l.Intensity,
+	}}
+}
@@ -0,0 +1,96 @@
+package lighting
+
+import (
+	color "github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+/*
+Light is implemented by every kind of light source that can be placed in a
+world.
+
+Shading and shadows only need to know where the light comes from, as seen
+from the point being lit: a light is described by its samples at that point.
+Point and spot lights have a single sample, area lights one per cell of their
+surface, and directional lights a single sample infinitely far away.
+*/
+type Light interface {
+	// color (and brightness) of the light, which also lights the ambient term
+	GetIntensity() color.Color
+	// samples of the light as seen from the point, at least one
+	SamplesAt(point core.Point) []LightSample
+}
+
+// LightSample is the light reaching a point from (a part of) a light source
+type LightSample struct {
+	// normalized vector from the point towards the light
+	Direction core.Vector
+	// distance from the point to the light along Direction, math.Inf(1) for
+	// lights that are infinitely far away
+	Distance float64
+	// intensity of the light reaching the point when nothing blocks it
+	Intensity color.Color
+}
+
+/*
+Attenuation is how the light of a light source fades with the distance d to
+the light: its intensity is divided by Constant + Linear*d + Quadratic*d².
+
+The zero value (like NoAttenuation) doesn't fade the light at all.
+InverseSquareAttenuation is how real lights fade, and with it the intensity
+of a light is the illuminance of a surface 1 unit away (see units.go for
+lights in physical units).
+*/
+type Attenuation struct {
+	Constant  float64
+	Linear    float64
+	Quadratic float64
+}
+
+var (
+	NoAttenuation            = Attenuation{Constant: 1}
+	LinearAttenuation        = Attenuation{Linear: 1}
+	InverseSquareAttenuation = Attenuation{Quadratic: 1}
+)
+
+// Factor by which the intensity of a light distance away is multiplied
+func (a Attenuation) At(distance float64) float64 {
+	denominator := a.Constant + a.Linear*distance + a.Quadratic*distance*distance
+	// no attenuation, and no infinite light right at the light either
+	if denominator <= 0 {
+		return 1
+	}
+	return 1 / denominator
+}
+
+// Light emitted from a single point in every direction, which casts sharp
+// shadows
+type PointLight struct {
+	Intensity   color.Color
+	Position    core.Point
+	Attenuation Attenuation
+}
+
+func NewPointLight(intensity color.Color, pos core.Point) *PointLight {
+	return &PointLight{Intensity: intensity, Position: pos}
+}
+
+func (l *PointLight) GetIntensity() color.Color {
+	return l.Intensity
+}
+
+func (l *PointLight) SamplesAt(point core.Point) []LightSample {
+	return []LightSample{sampleTowards(point, l.Position, l.Intensity, l.Attenuation)}
+}
+
+// Sample of the light coming from the position of a light to the point, faded
+// by the distance between them
+func sampleTowards(point core.Point, position core.Point, intensity color.Color, attenuation Attenuation) LightSample {
+	v := position.Subtract(point)
+	distance := v.Magnitude()
+	return LightSample{
+		Direction: *v.Normalize(),
+		Distance:  distance,
+		Intensity: *intensity.ScalarMultiply(attenuation.At(distance)),
+	}
+}
@@ -6,27 +6,29 @@ import (
 	color "github.com/Naveenaidu/gray/src/core/color"
 	core "github.com/Naveenaidu/gray/src/core/math"
 	"github.com/Naveenaidu/gray/src/material"
+	"github.com/Naveenaidu/gray/src/pattern"
 	"github.com/Naveenaidu/gray/src/shape"
 )
 
 /*
-To calculate the normal for a sphere at a point, we do the following:
-1. Convert the sphere to object world
+To calculate the normal for a shape at a point, we do the following:
+1. Convert the point to object world (through all the groups the shape is in)
 2. Calculate the normal in object world
 3. Convert the object world normal into world coordinate system
 */
-func NormalAt(s shape.Sphere, p core.Point) core.Vector {
+func NormalAt(s shape.Shape, p core.Point) core.Vector {
+	return NormalAtHit(shape.NewIntersection(0, s), p)
+}
+
+// Same as NormalAt, but hands the intersection to the shape, so that shapes
+// like smooth triangles can use the u/v of the hit to interpolate the normal
+func NormalAtHit(hit shape.Intersection, p core.Point) core.Vector {
+	s := hit.Object
 	// FIXME: Check if the transform can be invertible
-	// get the sphere to be at the origin in object world
-	invertTransformM := s.Transform.Inverse()
-	objectPoint := invertTransformM.Multiply(*p.ToMatrix()).ToPoint()
-	objectNormal := objectPoint.Subtract(*core.ObjectOrigin())
-	// use transpose of inverse matrix to convert vector in object space to
-	// world space
-	// world_normal ← transpose(inverse(sphere.transform)) * object_normal
-	worldNormal := invertTransformM.Transpose().Multiply(*objectNormal.ToMatrix()).ToVector()
-
-	return *worldNormal.Normalize()
+	// get the shape to be at the origin in object world
+	objectPoint := shape.WorldToObject(s, p)
+	objectNormal := s.LocalNormalAt(objectPoint, hit)
+	return shape.NormalToWorld(s, objectNormal)
 }
 
 func Reflect(in core.Vector, normal core.Vector) core.Vector {
@@ -39,35 +41,100 @@ func Reflect(in core.Vector, normal core.Vector) core.Vector {
 	return *reflection
 }
 
-type Light struct {
-	Intensity color.Color
-	Position  core.Point
+// Color of the pattern at a world space point on the shape. The point is
+// converted into the object space of the shape (through all its parents), and
+// from there into the space of the pattern
+func PatternAtShape(pat pattern.Pattern, s shape.Shape, worldPoint core.Point) color.Color {
+	objectPoint := shape.WorldToObject(s, worldPoint)
+	return pattern.ColorAt(pat, objectPoint)
 }
 
-func NewLight(intensity color.Color, pos core.Point) Light {
-	return Light{intensity, pos}
+/*
+Lighting is the phong shading of the point on the object, as seen along eyev.
+
+lightIntensity is the fraction of the light that reaches the point: 0 when it
+is fully in shadow, 1 when nothing blocks the light, and in between in the
+penumbra of an area light.
+
+The object is only needed to place the pattern of the material (if any) on
+its surface; it may be nil for materials without a pattern, in which case a
+pattern is evaluated as if the point was in object space.
+*/
+func Lighting(material material.Material, object shape.Shape, light Light, point core.Point, eyev core.Vector, normalv core.Vector, lightIntensity float64) color.Color {
+	surfaceColor := SurfaceColor(material, object, point)
+	samples := light.SamplesAt(point)
+	ambient := AmbientLighting(material, surfaceColor, SampledIntensity(samples))
+	direct := DirectLighting(material, surfaceColor, samples, eyev, normalv, lightIntensity)
+
+	return *color.AddColors([]color.Color{ambient, direct})
+}
+
+// Color of the material at the point on the object: the color of its pattern
+// if it has one, its flat color otherwise. See Lighting about the object
+func SurfaceColor(material material.Material, object shape.Shape, point core.Point) color.Color {
+	if material.Pattern == nil {
+		return material.Color
+	}
+	if object == nil {
+		return pattern.ColorAt(material.Pattern, point)
+	}
+	return PatternAtShape(material.Pattern, object, point)
 }
 
-func Lighting(material material.Material, light Light, point core.Point, eyev core.Vector, normalv core.Vector, inShadow bool) color.Color {
+// Ambient part of the phong shading, for a surface of the given color lit by
+// an ambient light of the given intensity. Unlike the direct lighting, it is
+// the same everywhere, even in shadows
+func AmbientLighting(material material.Material, surfaceColor color.Color, intensity color.Color) color.Color {
 	// combine the surface color with the light's color/intensity
-	effectiveColor := color.MultiplyColors([]color.Color{material.Color, light.Intensity})
+	effectiveColor := color.MultiplyColors([]color.Color{surfaceColor, intensity})
+	return *effectiveColor.ScalarMultiply(material.Ambient)
+}
+
+/*
+SampledIntensity is the intensity of a light at a point, averaged over its
+samples at that point (see Light.SamplesAt): the intensity of the light once it
+has faded with the distance, or outside the cone of a spot light. This is the
+intensity that lights the ambient term.
+*/
+func SampledIntensity(samples []LightSample) color.Color {
+	sum := color.Color{}
+	for _, sample := range samples {
+		sum = *color.AddColors([]color.Color{sum, sample.Intensity})
+	}
+	return *sum.ScalarMultiply(1 / float64(len(samples)))
+}
+
+/*
+DirectLighting is the diffuse and specular parts of the phong shading, i.e
+the light that reaches the point directly from the light source, scaled by
+lightIntensity (see Lighting).
+
+samples are the samples of the light at the point (see Light.SamplesAt),
+which callers that also check the shadow of the point can share with it. The
+diffuse and specular parts are averaged over the samples, each of them
+lighting the point from its own direction with its own intensity.
+*/
+func DirectLighting(material material.Material, surfaceColor color.Color, samples []LightSample, eyev core.Vector, normalv core.Vector, lightIntensity float64) color.Color {
+	// ignore diffuse and specular components if point is in shadow
+	if lightIntensity <= 0 {
+		return *color.Black
+	}
 
-	// find the direction of light source
-	lightV := light.Position.Subtract(point).Normalize()
+	sum := color.Black
+	for _, sample := range samples {
+		// combine the surface color with the light's color/intensity
+		effectiveColor := color.MultiplyColors([]color.Color{surfaceColor, sample.Intensity})
 
-	// compute the ambient contribution
-	ambient := effectiveColor.ScalarMultiply(material.Ambient)
-	diffuse := color.Black
-	specular := color.Black
+		// direction of the light source
+		lightV := sample.Direction
 
-	// ignore shadow and diffuse component if point is in shadow
-	if !inShadow {
 		// light_dot_normal represents the cosine of the angle between the
 		// light vector and the normal vector. A negative number means the
 		// light is on the other side of the surface.
 		lightDotNormal := lightV.DotProduct(normalv)
 		if lightDotNormal > 0 {
-			diffuse = effectiveColor.ScalarMultiply(material.Diffuse).ScalarMultiply(lightDotNormal)
+			diffuse := effectiveColor.ScalarMultiply(material.Diffuse).ScalarMultiply(lightDotNormal)
+			sum = color.AddColors([]color.Color{*sum, *diffuse})
 		}
 
 		// reflect_dot_eye represents the cosine of the angle between the
@@ -77,9 +144,10 @@ func Lighting(material material.Material, light Light, point core.Point, eyev co
 		reflectDotEye := reflectV.DotProduct(eyev)
 		if reflectDotEye > 0 {
 			factor := math.Pow(reflectDotEye, float64(material.Shininess))
-			specular = light.Intensity.ScalarMultiply(material.Specular).ScalarMultiply(factor)
+			specular := sample.Intensity.ScalarMultiply(material.Specular).ScalarMultiply(factor)
+			sum = color.AddColors([]color.Color{*sum, *specular})
 		}
 	}
 
-	return *color.AddColors([]color.Color{*ambient, *diffuse, *specular})
+	return *sum.ScalarMultiply(lightIntensity / float64(len(samples)))
 }
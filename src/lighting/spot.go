@@ -0,0 +1,66 @@
+package lighting
+
+import (
+	"math"
+
+	color "github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+/*
+SpotLight is a point light that only shines within a cone around Direction.
+
+Angle is the angle between the axis of the cone and its edge: points outside
+the cone are not lit at all. The light fades out over the last Falloff
+radians before the edge, which softens the edge of the circle of light. With
+a Falloff of 0 the edge is sharp.
+*/
+type SpotLight struct {
+	Intensity color.Color
+	Position  core.Point
+	// direction the spot is pointing at
+	Direction core.Vector
+	// both in radians
+	Angle       float64
+	Falloff     float64
+	Attenuation Attenuation
+}
+
+func NewSpotLight(intensity color.Color, pos core.Point, direction core.Vector, angle float64, falloff float64) *SpotLight {
+	return &SpotLight{
+		Intensity: intensity,
+		Position:  pos,
+		Direction: *direction.Normalize(),
+		Angle:     angle,
+		Falloff:   falloff,
+	}
+}
+
+func (l *SpotLight) GetIntensity() color.Color {
+	return l.Intensity
+}
+
+func (l *SpotLight) SamplesAt(point core.Point) []LightSample {
+	sample := sampleTowards(point, l.Position, l.Intensity, l.Attenuation)
+	sample.Intensity = *sample.Intensity.ScalarMultiply(l.coneFactor(sample.Direction))
+	return []LightSample{sample}
+}
+
+// How much of the light reaches a point seen from the spot in the direction
+// opposite to toLight: 1 within the cone, 0 outside of it and smoothly fading
+// in between
+func (l *SpotLight) coneFactor(toLight core.Vector) float64 {
+	cosAngle := toLight.Negate().DotProduct(*l.Direction.Normalize())
+	cosOuter := math.Cos(l.Angle)
+	cosInner := math.Cos(max(l.Angle-l.Falloff, 0))
+
+	if cosAngle < cosOuter {
+		return 0
+	}
+	if cosAngle >= cosInner {
+		return 1
+	}
+	// smoothstep between the edge of the cone and the end of the falloff
+	x := (cosAngle - cosOuter) / (cosInner - cosOuter)
+	return x * x * (3 - 2*x)
+}
@@ -0,0 +1,59 @@
+package lighting
+
+import (
+	"math"
+)
+
+/*
+Lights in physical units.
+
+The intensity of a light is unitless: a white surface facing a light of
+intensity 1 is lit at full brightness. Real fixtures are rated by the light
+they emit instead, their luminous flux in lumens (or their power in watts),
+and the light they shed on a surface fades with the square of the distance.
+
+With distances in meters and InverseSquareAttenuation, a light of intensity I
+lights a surface 1 meter away with an illuminance of I lux when I is its
+luminous intensity (in candela). The functions below give that intensity,
+divided by the exposure: the illuminance (in lux) at which a white surface is
+lit at full brightness. A few hundred lux is a well lit room, around 100000
+is direct sunlight.
+*/
+
+// Luminous efficacy (lumens per watt) of common light sources, to convert
+// their power into luminous flux with WattsToLumens
+const (
+	IncandescentEfficacy = 15.0
+	HalogenEfficacy      = 20.0
+	FluorescentEfficacy  = 60.0
+	LEDEfficacy          = 90.0
+	// efficacy of a light emitting all its power at 555nm, where the eye is
+	// most sensitive. No light does better
+	MaxEfficacy = 683.0
+)
+
+// Luminous flux of a light consuming the given power
+func WattsToLumens(watts float64, efficacy float64) float64 {
+	return watts * efficacy
+}
+
+// Intensity of a point light (or an area light) emitting lumens evenly in
+// every direction, for the given exposure
+func PointIntensity(lumens float64, exposure float64) float64 {
+	// the flux is spread over the whole sphere of 4π steradians
+	return lumens / (4 * math.Pi) / exposure
+}
+
+// Intensity of a spot light emitting lumens within a cone of the given angle
+// (see SpotLight), for the given exposure
+func SpotIntensity(lumens float64, angle float64, exposure float64) float64 {
+	// solid angle of the cone
+	steradians := 2 * math.Pi * (1 - math.Cos(angle))
+	return lumens / steradians / exposure
+}
+
+// Intensity of a directional light lighting surfaces facing it with the given
+// illuminance (in lux, e.g. around 100000 for the sun), for the given exposure
+func DirectionalIntensity(lux float64, exposure float64) float64 {
+	return lux / exposure
+}
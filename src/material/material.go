@@ -2,6 +2,7 @@ package material
 
 import (
 	color "github.com/Naveenaidu/gray/src/core/color"
+	"github.com/Naveenaidu/gray/src/pattern"
 )
 
 type Material struct {
@@ -10,8 +11,26 @@ type Material struct {
 	Diffuse   float64 // ranges between 0 and 1
 	Specular  float64 // ranges between 0 and 1
 	Shininess int     // ranges between 10 and 200
+	// 0 is non-reflective, 1 is a perfect mirror
+	Reflective float64
+	// 0 is opaque, 1 lets all the light through
+	Transparency float64
+	// how much light bends when entering the material, see the constants
+	// below for some common ones. 0 is the same as Vacuum
+	RefractiveIndex float64
+	// optional, when set the surface is colored by the pattern instead of Color
+	Pattern pattern.Pattern
 }
 
+// Refractive indices of some common materials
+const (
+	Vacuum  = 1.0
+	Air     = 1.00029
+	Water   = 1.333
+	Glass   = 1.52
+	Diamond = 2.417
+)
+
 func DefaultMaterial() Material {
 	return Material{
 		Color:     *color.NewColor(1, 1, 1),
@@ -19,5 +38,7 @@ func DefaultMaterial() Material {
 		Diffuse:   0.9,
 		Specular:  0.9,
 		Shininess: 200.0,
+		// opaque, so the index only matters once Transparency is set
+		RefractiveIndex: Vacuum,
 	}
 }
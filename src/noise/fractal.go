@@ -0,0 +1,52 @@
+package noise
+
+import "math"
+
+// Source is a noise function, like Perlin or Simplex (or the methods of the
+// same name of a seeded Noise)
+type Source func(x float64, y float64, z float64) float64
+
+// Each octave of the fractal sums doubles the frequency (lacunarity) and halves
+// the amplitude (gain) of the previous one
+const (
+	lacunarity = 2.0
+	gain       = 0.5
+)
+
+/*
+FBM (fractional Brownian motion) sums octaves of the noise at increasing
+frequencies and decreasing amplitudes, which adds finer and finer detail to
+the noise. The sum is normalized, so the result stays within the range of the
+source.
+*/
+func FBM(src Source, x float64, y float64, z float64, octaves int) float64 {
+	return fractal(src, x, y, z, octaves, func(n float64) float64 { return n })
+}
+
+/*
+Turbulence is like FBM but sums the absolute values of the octaves, which
+gives sharp creases where the noise crosses zero (like the veins of marble).
+The result is within [0, 1].
+*/
+func Turbulence(src Source, x float64, y float64, z float64, octaves int) float64 {
+	return fractal(src, x, y, z, octaves, math.Abs)
+}
+
+func fractal(src Source, x float64, y float64, z float64, octaves int, shape func(float64) float64) float64 {
+	sum := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+	total := 0.0
+
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * shape(src(x*frequency, y*frequency, z*frequency))
+		total += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
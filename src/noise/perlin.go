@@ -0,0 +1,84 @@
+package noise
+
+import "math"
+
+// Perlin noise at the point (x, y, z), using the reference permutation table
+func Perlin(x float64, y float64, z float64) float64 {
+	return defaultNoise.Perlin(x, y, z)
+}
+
+/*
+Perlin is Ken Perlin's improved gradient noise at the point (x, y, z).
+
+The result varies smoothly between roughly -1 and 1, is 0 at every point with
+integer coordinates, and is always the same for the same point.
+*/
+func (n *Noise) Perlin(x float64, y float64, z float64) float64 {
+	perm := &n.perm
+
+	// unit cube that contains the point
+	xi := int(math.Floor(x)) & 255
+	yi := int(math.Floor(y)) & 255
+	zi := int(math.Floor(z)) & 255
+
+	// relative position of the point in the cube
+	x -= math.Floor(x)
+	y -= math.Floor(y)
+	z -= math.Floor(z)
+
+	u := fade(x)
+	v := fade(y)
+	w := fade(z)
+
+	// hash the coordinates of the 8 corners of the cube
+	a := perm[xi] + yi
+	aa := perm[a] + zi
+	ab := perm[a+1] + zi
+	b := perm[xi+1] + yi
+	ba := perm[b] + zi
+	bb := perm[b+1] + zi
+
+	// blend the contributions of the corners
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(perm[aa], x, y, z), grad(perm[ba], x-1, y, z)),
+			lerp(u, grad(perm[ab], x, y-1, z), grad(perm[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(perm[aa+1], x, y, z-1), grad(perm[ba+1], x-1, y, z-1)),
+			lerp(u, grad(perm[ab+1], x, y-1, z-1), grad(perm[bb+1], x-1, y-1, z-1))))
+}
+
+// 6t^5 - 15t^4 + 10t^3, eases the coordinates so that the noise is smooth
+// across the faces of the cubes
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t float64, a float64, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// dot product of (x, y, z) with one of 12 gradient directions (the edges of a
+// cube), picked by the low 4 bits of the hash
+func grad(hash int, x float64, y float64, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	var v float64
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	} else {
+		v = z
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
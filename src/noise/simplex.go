@@ -0,0 +1,108 @@
+package noise
+
+import "math"
+
+// gradient directions of the simplex noise: the midpoints of the edges of a
+// cube
+var grad3 = [12][3]float64{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+// skewing and unskewing factors for 3 dimensions
+const (
+	f3 = 1.0 / 3
+	g3 = 1.0 / 6
+)
+
+// Simplex noise at the point (x, y, z), using the reference permutation table
+func Simplex(x float64, y float64, z float64) float64 {
+	return defaultNoise.Simplex(x, y, z)
+}
+
+/*
+Simplex is Ken Perlin's simplex noise at the point (x, y, z), following
+Stefan Gustavson's reference implementation.
+
+Instead of blending the 8 corners of a cube like Perlin noise, it sums the
+contributions of the 4 corners of the tetrahedron (simplex) that contains the
+point, which is cheaper and has fewer directional artifacts. The result is
+within [-1, 1].
+*/
+func (n *Noise) Simplex(x float64, y float64, z float64) float64 {
+	perm := &n.perm
+
+	// skew the input space to find the cell (made of 6 simplices) that
+	// contains the point
+	s := (x + y + z) * f3
+	i := math.Floor(x + s)
+	j := math.Floor(y + s)
+	k := math.Floor(z + s)
+
+	// unskew the origin of the cell back to (x, y, z) space, and find the
+	// distances from it
+	t := (i + j + k) * g3
+	x0 := x - (i - t)
+	y0 := y - (j - t)
+	z0 := z - (k - t)
+
+	// find out in which of the 6 simplices of the cell the point is, by
+	// ordering the coordinates. (i1, j1, k1) and (i2, j2, k2) are the offsets
+	// of its second and third corners
+	var i1, j1, k1, i2, j2, k2 int
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else {
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	// distances from the other 3 corners
+	x1 := x0 - float64(i1) + g3
+	y1 := y0 - float64(j1) + g3
+	z1 := z0 - float64(k1) + g3
+	x2 := x0 - float64(i2) + 2*g3
+	y2 := y0 - float64(j2) + 2*g3
+	z2 := z0 - float64(k2) + 2*g3
+	x3 := x0 - 1 + 3*g3
+	y3 := y0 - 1 + 3*g3
+	z3 := z0 - 1 + 3*g3
+
+	// hash the corners to pick their gradients
+	ii := int(i) & 255
+	jj := int(j) & 255
+	kk := int(k) & 255
+	gi0 := perm[ii+perm[jj+perm[kk]]] % 12
+	gi1 := perm[ii+i1+perm[jj+j1+perm[kk+k1]]] % 12
+	gi2 := perm[ii+i2+perm[jj+j2+perm[kk+k2]]] % 12
+	gi3 := perm[ii+1+perm[jj+1+perm[kk+1]]] % 12
+
+	// sum the contributions of the corners, scaled so that the result is
+	// within [-1, 1]
+	return 32 * (corner(gi0, x0, y0, z0) + corner(gi1, x1, y1, z1) +
+		corner(gi2, x2, y2, z2) + corner(gi3, x3, y3, z3))
+}
+
+// contribution of a corner of the simplex at distance (x, y, z) from the
+// point, which falls off to 0 away from the corner
+func corner(gi int, x float64, y float64, z float64) float64 {
+	t := 0.6 - x*x - y*y - z*z
+	if t < 0 {
+		return 0
+	}
+	g := grad3[gi]
+	t *= t
+	return t * t * (g[0]*x + g[1]*y + g[2]*z)
+}
@@ -0,0 +1,27 @@
+package pattern
+
+import (
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Average of two patterns, e.g two stripe patterns at right angles blend into
+// a plaid
+type Blend struct {
+	A         Pattern
+	B         Pattern
+	Transform core.Matrix
+}
+
+func NewBlend(a Pattern, b Pattern) *Blend {
+	return &Blend{A: a, B: b, Transform: *core.IdentityMatrix()}
+}
+
+func (b *Blend) LocalColorAt(p core.Point) color.Color {
+	sum := color.AddColors([]color.Color{ColorAt(b.A, p), ColorAt(b.B, p)})
+	return *sum.ScalarMultiply(0.5)
+}
+
+func (b *Blend) GetTransform() core.Matrix {
+	return b.Transform
+}
@@ -0,0 +1,31 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// 3D checkerboard of unit cubes, alternating between a and b along every axis
+type Checker struct {
+	A         Pattern
+	B         Pattern
+	Transform core.Matrix
+}
+
+func NewChecker(a color.Color, b color.Color) *Checker {
+	return &Checker{A: NewSolid(a), B: NewSolid(b), Transform: *core.IdentityMatrix()}
+}
+
+func (c *Checker) LocalColorAt(p core.Point) color.Color {
+	sum := math.Floor(p.X+core.EPSILON) + math.Floor(p.Y+core.EPSILON) + math.Floor(p.Z+core.EPSILON)
+	if isEven(sum) {
+		return ColorAt(c.A, p)
+	}
+	return ColorAt(c.B, p)
+}
+
+func (c *Checker) GetTransform() core.Matrix {
+	return c.Transform
+}
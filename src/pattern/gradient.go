@@ -0,0 +1,29 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Linear blend from a to b along x, repeating every unit
+type Gradient struct {
+	A         Pattern
+	B         Pattern
+	Transform core.Matrix
+}
+
+func NewGradient(a color.Color, b color.Color) *Gradient {
+	return &Gradient{A: NewSolid(a), B: NewSolid(b), Transform: *core.IdentityMatrix()}
+}
+
+func (g *Gradient) LocalColorAt(p core.Point) color.Color {
+	// color = a + (b - a) * fraction of x
+	fraction := p.X - math.Floor(p.X)
+	return mix(ColorAt(g.A, p), ColorAt(g.B, p), fraction)
+}
+
+func (g *Gradient) GetTransform() core.Matrix {
+	return g.Transform
+}
@@ -0,0 +1,45 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/noise"
+)
+
+/*
+Granite is a speckled mix of a and b, from many octaves of turbulence, with
+mostly a and grains of b.
+
+Octaves is how fine the grains get.
+*/
+type Granite struct {
+	A       Pattern
+	B       Pattern
+	Octaves int
+	// set it to noise.New(seed) for a different (but reproducible) granite
+	Noise     *noise.Noise
+	Transform core.Matrix
+}
+
+func NewGranite(a color.Color, b color.Color) *Granite {
+	return &Granite{
+		A:         NewSolid(a),
+		B:         NewSolid(b),
+		Octaves:   6,
+		Noise:     noise.Default(),
+		Transform: *core.IdentityMatrix(),
+	}
+}
+
+func (g *Granite) LocalColorAt(p core.Point) color.Color {
+	// the turbulence is mostly small, so stretch it to get contrasted grains
+	turbulence := noise.Turbulence(perlin(g.Noise), p.X, p.Y, p.Z, g.Octaves)
+	t := math.Min(1, 3*turbulence)
+	return mix(ColorAt(g.A, p), ColorAt(g.B, p), t)
+}
+
+func (g *Granite) GetTransform() core.Matrix {
+	return g.Transform
+}
@@ -0,0 +1,47 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/noise"
+)
+
+/*
+Marble is made of bands of a and b along x (a sine wave), twisted into veins
+by turbulence.
+
+Turbulence is how much the bands are twisted (0 gives straight bands), and
+Octaves how fine the detail of the veins is.
+*/
+type Marble struct {
+	A          Pattern
+	B          Pattern
+	Turbulence float64
+	Octaves    int
+	// set it to noise.New(seed) for a different (but reproducible) marble
+	Noise     *noise.Noise
+	Transform core.Matrix
+}
+
+func NewMarble(a color.Color, b color.Color) *Marble {
+	return &Marble{
+		A:          NewSolid(a),
+		B:          NewSolid(b),
+		Turbulence: 5,
+		Octaves:    4,
+		Noise:      noise.Default(),
+		Transform:  *core.IdentityMatrix(),
+	}
+}
+
+func (m *Marble) LocalColorAt(p core.Point) color.Color {
+	turbulence := noise.Turbulence(perlin(m.Noise), p.X, p.Y, p.Z, m.Octaves)
+	t := 0.5 + 0.5*math.Sin(p.X+m.Turbulence*turbulence)
+	return mix(ColorAt(m.A, p), ColorAt(m.B, p), t)
+}
+
+func (m *Marble) GetTransform() core.Matrix {
+	return m.Transform
+}
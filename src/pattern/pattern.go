@@ -0,0 +1,61 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/noise"
+)
+
+/*
+Pattern gives a color to every point of a surface, instead of a single flat
+color.
+
+Like shapes, each pattern lives in its own pattern space: LocalColorAt
+receives a point that has already been converted into pattern space. The
+pattern's transform is relative to the object it is applied to, so moving,
+scaling or rotating the object carries the pattern along with it.
+
+Patterns can be combined into a tree: the two colors of stripes, rings,
+gradients and checkers are patterns themselves (Solid for a plain color), and
+Blend and Perturb wrap other patterns. The transform of a pattern nested in
+another is relative to the pattern space of its parent.
+*/
+type Pattern interface {
+	// color of the pattern at a point in pattern space
+	LocalColorAt(p core.Point) color.Color
+	GetTransform() core.Matrix
+}
+
+// Color of the pattern at a point given in the space the pattern is placed in:
+// the object space of the shape the pattern is applied to, or the pattern space
+// of the parent pattern for nested patterns
+func ColorAt(pat Pattern, p core.Point) color.Color {
+	transform := pat.GetTransform()
+	patternPoint := transform.Inverse().Multiply(*p.ToMatrix()).ToPoint()
+	return pat.LocalColorAt(*patternPoint)
+}
+
+// Whether floor(v) is even, which is what alternates the colors of the
+// stripe, ring and checker patterns. The floor is taken of a value nudged by
+// EPSILON, so that points computed right on a boundary (which can end up
+// just below it because of rounding) don't flicker between the two colors
+func isEven(v float64) bool {
+	return int(math.Floor(v+core.EPSILON))%2 == 0
+}
+
+// Linear interpolation between the colors a (t = 0) and b (t = 1)
+func mix(a color.Color, b color.Color, t float64) color.Color {
+	distance := color.SubtractColors([]color.Color{b, a})
+	return *color.AddColors([]color.Color{a, *distance.ScalarMultiply(t)})
+}
+
+// Perlin noise of n, falling back to the reference permutation table for
+// patterns built without a Noise
+func perlin(n *noise.Noise) noise.Source {
+	if n == nil {
+		n = noise.Default()
+	}
+	return n.Perlin
+}
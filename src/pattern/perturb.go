@@ -0,0 +1,43 @@
+package pattern
+
+import (
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/noise"
+)
+
+/*
+Perturb jitters the point at which the wrapped pattern is looked up, using
+Perlin noise, so that straight lines of the pattern become wavy and organic.
+
+Scale is how far (at most, roughly) the point is moved along each axis.
+*/
+type Perturb struct {
+	Pattern Pattern
+	Scale   float64
+	// noise used to jitter the point, set it to noise.New(seed) for a
+	// different (but reproducible) jitter
+	Noise     *noise.Noise
+	Transform core.Matrix
+}
+
+func NewPerturb(pat Pattern, scale float64) *Perturb {
+	return &Perturb{Pattern: pat, Scale: scale, Noise: noise.Default(), Transform: *core.IdentityMatrix()}
+}
+
+func (pt *Perturb) LocalColorAt(p core.Point) color.Color {
+	// sample the noise at shifted points, so that the jitter along each axis
+	// is different
+	n := perlin(pt.Noise)
+	jitter := core.NewVector(
+		n(p.X, p.Y, p.Z),
+		n(p.X, p.Y, p.Z+1),
+		n(p.X, p.Y, p.Z+2),
+	)
+	perturbed := p.AddVector(*jitter.ScalarMultiply(pt.Scale))
+	return ColorAt(pt.Pattern, *perturbed)
+}
+
+func (pt *Perturb) GetTransform() core.Matrix {
+	return pt.Transform
+}
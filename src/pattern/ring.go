@@ -0,0 +1,30 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Concentric rings of a and b around the y axis, each a unit wide
+type Ring struct {
+	A         Pattern
+	B         Pattern
+	Transform core.Matrix
+}
+
+func NewRing(a color.Color, b color.Color) *Ring {
+	return &Ring{A: NewSolid(a), B: NewSolid(b), Transform: *core.IdentityMatrix()}
+}
+
+func (r *Ring) LocalColorAt(p core.Point) color.Color {
+	if isEven(math.Sqrt(p.X*p.X + p.Z*p.Z)) {
+		return ColorAt(r.A, p)
+	}
+	return ColorAt(r.B, p)
+}
+
+func (r *Ring) GetTransform() core.Matrix {
+	return r.Transform
+}
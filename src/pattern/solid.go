@@ -0,0 +1,24 @@
+package pattern
+
+import (
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Same color everywhere, the leaf of a tree of patterns
+type Solid struct {
+	Color color.Color
+}
+
+func NewSolid(c color.Color) *Solid {
+	return &Solid{Color: c}
+}
+
+func (s *Solid) LocalColorAt(p core.Point) color.Color {
+	return s.Color
+}
+
+// the color is the same everywhere, so there is nothing to transform
+func (s *Solid) GetTransform() core.Matrix {
+	return *core.IdentityMatrix()
+}
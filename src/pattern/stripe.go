@@ -0,0 +1,28 @@
+package pattern
+
+import (
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Stripes of a and b, alternating every unit along x
+type Stripe struct {
+	A         Pattern
+	B         Pattern
+	Transform core.Matrix
+}
+
+func NewStripe(a color.Color, b color.Color) *Stripe {
+	return &Stripe{A: NewSolid(a), B: NewSolid(b), Transform: *core.IdentityMatrix()}
+}
+
+func (s *Stripe) LocalColorAt(p core.Point) color.Color {
+	if isEven(p.X) {
+		return ColorAt(s.A, p)
+	}
+	return ColorAt(s.B, p)
+}
+
+func (s *Stripe) GetTransform() core.Matrix {
+	return s.Transform
+}
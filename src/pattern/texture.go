@@ -0,0 +1,106 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/rendering"
+)
+
+// UVPattern is a 2D pattern, that gives a color to every u, v within [0, 1)
+type UVPattern interface {
+	UVColorAt(u float64, v float64) color.Color
+}
+
+/*
+TextureMap puts a 2D pattern on the surface of a shape: the point is turned
+into u, v by the mapping (which should fit the shape, e.g SphericalMap for a
+sphere), and the color is looked up in the 2D pattern.
+*/
+type TextureMap struct {
+	Pattern   UVPattern
+	Mapping   UVMap
+	Transform core.Matrix
+}
+
+func NewTextureMap(uvPattern UVPattern, mapping UVMap) *TextureMap {
+	return &TextureMap{Pattern: uvPattern, Mapping: mapping, Transform: *core.IdentityMatrix()}
+}
+
+func (t *TextureMap) LocalColorAt(p core.Point) color.Color {
+	u, v := t.Mapping(p)
+	return t.Pattern.UVColorAt(u, v)
+}
+
+func (t *TextureMap) GetTransform() core.Matrix {
+	return t.Transform
+}
+
+// Checkerboard of Width squares along u and Height squares along v. Useful to
+// check how a mapping stretches a texture
+type UVCheckers struct {
+	Width  int
+	Height int
+	A      color.Color
+	B      color.Color
+}
+
+func NewUVCheckers(width int, height int, a color.Color, b color.Color) *UVCheckers {
+	return &UVCheckers{Width: width, Height: height, A: a, B: b}
+}
+
+func (c *UVCheckers) UVColorAt(u float64, v float64) color.Color {
+	u2 := math.Floor(u * float64(c.Width))
+	v2 := math.Floor(v * float64(c.Height))
+	if int(u2+v2)%2 == 0 {
+		return c.A
+	}
+	return c.B
+}
+
+// How an image texture computes the color between the centers of its pixels
+type TextureFilter int
+
+const (
+	// color of the closest pixel, which looks blocky when magnified
+	NearestFilter TextureFilter = iota
+	// weighted average of the 4 closest pixels, which looks smooth
+	BilinearFilter
+)
+
+/*
+ImageTexture samples an image (e.g a map of the earth, or a logo) stretched
+over u, v: u = 0 is the left edge of the image and v = 0 its bottom edge.
+*/
+type ImageTexture struct {
+	Canvas *rendering.Canvas
+	Filter TextureFilter
+}
+
+func NewImageTexture(canvas *rendering.Canvas) *ImageTexture {
+	return &ImageTexture{Canvas: canvas, Filter: NearestFilter}
+}
+
+func (t *ImageTexture) UVColorAt(u float64, v float64) color.Color {
+	// v goes up while the rows of the canvas go down
+	x := u * float64(t.Canvas.Width-1)
+	y := (1 - v) * float64(t.Canvas.Height-1)
+
+	if t.Filter == NearestFilter {
+		return t.pixelAt(int(math.Round(x)), int(math.Round(y)))
+	}
+
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	top := mix(t.pixelAt(int(x0), int(y0)), t.pixelAt(int(x0)+1, int(y0)), fx)
+	bottom := mix(t.pixelAt(int(x0), int(y0)+1), t.pixelAt(int(x0)+1, int(y0)+1), fx)
+	return mix(top, bottom, fy)
+}
+
+// pixel of the canvas, with the coordinates clamped to its edges
+func (t *ImageTexture) pixelAt(x int, y int) color.Color {
+	x = min(max(x, 0), t.Canvas.Width-1)
+	y = min(max(y, 0), t.Canvas.Height-1)
+	return t.Canvas.PixelAt(x, y)
+}
@@ -0,0 +1,129 @@
+package pattern
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+/*
+UVMap flattens the surface of a shape, turning a point (in the space of the
+texture, i.e the object space of the shape unless the texture is transformed)
+into 2D texture coordinates u and v, both within [0, 1).
+
+u goes from left to right and v from bottom to top.
+*/
+type UVMap func(p core.Point) (u float64, v float64)
+
+/*
+SphericalMap wraps a texture around a unit sphere, like a map of the earth
+around a globe: u goes once around the y axis and v from the south pole (0)
+to the north pole (1).
+*/
+func SphericalMap(p core.Point) (float64, float64) {
+	// azimuthal angle, within (-π, π], increasing clockwise when looking
+	// down the y axis
+	theta := math.Atan2(p.X, p.Z)
+
+	// polar angle, from 0 at the north pole to π at the south pole
+	radius := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+	phi := math.Acos(p.Y / radius)
+
+	// flip u so that it increases counterclockwise when seen from above
+	rawU := theta / (2 * math.Pi)
+	u := 1 - (rawU + 0.5)
+	// flip v so that 1 is the north pole
+	v := 1 - phi/math.Pi
+
+	return u, v
+}
+
+// PlanarMap tiles a texture over the xz plane, repeating every unit along x
+// (u) and z (v)
+func PlanarMap(p core.Point) (float64, float64) {
+	return fraction(p.X), fraction(p.Z)
+}
+
+// CylindricalMap wraps a texture around the y axis (u) and repeats it every
+// unit along y (v)
+func CylindricalMap(p core.Point) (float64, float64) {
+	theta := math.Atan2(p.X, p.Z)
+	rawU := theta / (2 * math.Pi)
+	u := 1 - (rawU + 0.5)
+
+	return u, fraction(p.Y)
+}
+
+// Face of a cube, as picked by CubeFaceUV
+type CubeFace int
+
+const (
+	CubeLeft CubeFace = iota
+	CubeRight
+	CubeFront
+	CubeBack
+	CubeUp
+	CubeDown
+)
+
+/*
+CubeFaceUV finds the face of the cube (from -1 to 1 along every axis) that
+the point is on, and the u, v of the point on that face.
+
+Each face is seen from outside of the cube, with the up face having the back
+at its top, and the down face having the front at its top.
+*/
+func CubeFaceUV(p core.Point) (CubeFace, float64, float64) {
+	absX, absY, absZ := math.Abs(p.X), math.Abs(p.Y), math.Abs(p.Z)
+	coord := math.Max(absX, math.Max(absY, absZ))
+
+	switch coord {
+	case p.X:
+		return CubeRight, halfFraction(1 - p.Z), halfFraction(p.Y + 1)
+	case -p.X:
+		return CubeLeft, halfFraction(p.Z + 1), halfFraction(p.Y + 1)
+	case p.Y:
+		return CubeUp, halfFraction(p.X + 1), halfFraction(1 - p.Z)
+	case -p.Y:
+		return CubeDown, halfFraction(p.X + 1), halfFraction(p.Z + 1)
+	case p.Z:
+		return CubeFront, halfFraction(p.X + 1), halfFraction(p.Y + 1)
+	}
+	return CubeBack, halfFraction(1 - p.X), halfFraction(p.Y + 1)
+}
+
+// column and row of each face in the unfolded cube used by CubeMap, with
+// row 0 at the bottom
+var cubeCross = map[CubeFace][2]int{
+	CubeLeft:  {0, 1},
+	CubeFront: {1, 1},
+	CubeRight: {2, 1},
+	CubeBack:  {3, 1},
+	CubeUp:    {1, 2},
+	CubeDown:  {1, 0},
+}
+
+/*
+CubeMap wraps a single texture around a cube, where the texture is the cube
+unfolded into a cross, 4 faces wide and 3 faces high:
+
+	       | up    |
+	left   | front | right | back
+	       | down  |
+*/
+func CubeMap(p core.Point) (float64, float64) {
+	face, u, v := CubeFaceUV(p)
+	cell := cubeCross[face]
+	return (float64(cell[0]) + u) / 4, (float64(cell[1]) + v) / 3
+}
+
+// v - floor(v), which unlike math.Mod is never negative
+func fraction(v float64) float64 {
+	return v - math.Floor(v)
+}
+
+// (v mod 2) / 2, i.e a coordinate from -1 to 1 along a face of a cube,
+// shifted by 1, turned into [0, 1)
+func halfFraction(v float64) float64 {
+	return fraction(v / 2)
+}
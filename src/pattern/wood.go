@@ -0,0 +1,48 @@
+package pattern
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/noise"
+)
+
+/*
+Wood is made of growth rings around the y axis, a unit apart, that fade from
+a to b. The rings are made irregular by turbulence.
+
+Turbulence is how much the rings are distorted (0 gives perfect circles, like
+the ring pattern), and Octaves how fine the distortion is.
+*/
+type Wood struct {
+	A          Pattern
+	B          Pattern
+	Turbulence float64
+	Octaves    int
+	// set it to noise.New(seed) for a different (but reproducible) wood
+	Noise     *noise.Noise
+	Transform core.Matrix
+}
+
+func NewWood(a color.Color, b color.Color) *Wood {
+	return &Wood{
+		A:          NewSolid(a),
+		B:          NewSolid(b),
+		Turbulence: 0.5,
+		Octaves:    3,
+		Noise:      noise.Default(),
+		Transform:  *core.IdentityMatrix(),
+	}
+}
+
+func (w *Wood) LocalColorAt(p core.Point) color.Color {
+	turbulence := noise.Turbulence(perlin(w.Noise), p.X, p.Y, p.Z, w.Octaves)
+	distance := math.Sqrt(p.X*p.X+p.Z*p.Z) + w.Turbulence*turbulence
+	t := distance - math.Floor(distance)
+	return mix(ColorAt(w.A, p), ColorAt(w.B, p), t)
+}
+
+func (w *Wood) GetTransform() core.Matrix {
+	return w.Transform
+}
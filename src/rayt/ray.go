@@ -1,7 +1,6 @@
 package rayt
 
 import (
-	"math"
 	"sort"
 
 	core "github.com/Naveenaidu/gray/src/core/math"
@@ -14,13 +13,16 @@ type Ray struct {
 }
 
 // TODO: Should "Intersection" be part of Ray struct
-type Intersection struct {
-	T      float64
-	Object shape.Sphere
+// Intersection is defined by the shape package, since shapes build their own
+// intersections (with u/v for triangles)
+type Intersection = shape.Intersection
+
+func NewIntersection(t float64, obj shape.Shape) Intersection {
+	return shape.NewIntersection(t, obj)
 }
 
-func NewIntersection(t float64, obj shape.Sphere) Intersection {
-	return Intersection{T: t, Object: obj}
+func NewIntersectionWithUV(t float64, obj shape.Shape, u float64, v float64) Intersection {
+	return shape.NewIntersectionWithUV(t, obj, u, v)
 }
 
 func (r Ray) Position(t float64) *core.Point {
@@ -30,33 +32,14 @@ func (r Ray) Position(t float64) *core.Point {
 	return newPosition
 }
 
-func (r Ray) IntersectSphere(s shape.Sphere) []Intersection {
-	intersections := []Intersection{}
-
-	//apply the inverse of the sphere trasnformation to  ray
-	transformedRay := r.Transform(s.Transform.Inverse())
-
-	// We assume the spehre is at origin
-	// vector from sphere origin, to the ray origin
-	sphereToRay := (transformedRay.Origin).Subtract(s.Center)
-
-	a := transformedRay.Direction.DotProduct(transformedRay.Direction)
-	b := 2 * (transformedRay.Direction.DotProduct(*sphereToRay))
-	c := sphereToRay.DotProduct(*sphereToRay) - math.Pow(s.Radius, 2)
-
-	discriminant := math.Pow(b, 2) - 4*a*c
-
-	// ray only intersects sphere if the discriminant is greater than zero
-	if discriminant >= 0 {
-		t1 := (-1*b - math.Sqrt(discriminant)) / (2 * a)
-		t2 := (-1*b + math.Sqrt(discriminant)) / (2 * a)
-
-		intersections = make([]Intersection, 2)
-		intersections[0] = NewIntersection(t1, s)
-		intersections[1] = NewIntersection(t2, s)
-	}
+// Intersect the ray with any shape. The ray is moved into the object space of
+// the shape, so the shape only has to know how to intersect itself at origin
+func (r Ray) Intersect(s shape.Shape) []Intersection {
+	//apply the inverse of the shape trasnformation to ray
+	transform := s.GetTransform()
+	transformedRay := r.Transform(transform.Inverse())
 
-	return intersections
+	return s.LocalIntersect(transformedRay.Origin, transformedRay.Direction)
 }
 
 func (r Ray) Hit(intersections []Intersection) *Intersection {
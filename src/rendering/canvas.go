@@ -1,8 +1,6 @@
 package rendering
 
 import (
-	"bufio"
-	"fmt"
 	"os"
 
 	core "github.com/Naveenaidu/gray/src/core/color"
@@ -63,40 +61,16 @@ func (c *Canvas) PixelAt(x int, y int) core.Color {
 	return c.Color[x][y]
 }
 
+// Write the canvas to a plain PPM file
 func (c *Canvas) WriteToPPM(fileName string) error {
-
 	f, err := os.Create(fileName)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	w := bufio.NewWriter(f)
-
-	//Write the PPM header
-	_, err = w.WriteString(fmt.Sprintf("P3\n%d %d\n255\n", c.Width, c.Height))
 	if err != nil {
 		return err
 	}
 
-	for y := 0; y < c.Height; y++ {
-		for x := 0; x < c.Width; x++ {
-			color := c.Color[x][y].Clamp()
-			r := int(color.R * 255)
-			g := int(color.G * 255)
-			b := int(color.B * 255)
-			_, err = w.WriteString(fmt.Sprintf("%d %d %d\n", r, g, b))
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Flush the writer
-	err = w.Flush()
-	if err != nil {
+	if err := c.EncodePlainPPM(f); err != nil {
+		f.Close()
 		return err
 	}
-
-	return nil
+	return f.Close()
 }
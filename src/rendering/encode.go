@@ -0,0 +1,167 @@
+package rendering
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	imageColor "image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	core "github.com/Naveenaidu/gray/src/core/color"
+)
+
+// Image formats the canvas can be encoded to
+type ImageFormat int
+
+const (
+	// plain (ASCII) PPM, P3
+	PlainPPM ImageFormat = iota
+	// raw (binary) PPM, P6, one byte per value
+	RawPPM
+	// raw PPM with two bytes per value (maxval 65535), for renders that need
+	// more than 256 levels per channel
+	RawPPM16
+	PNG
+)
+
+// lines of plain PPM files should not be longer than 70 characters
+const ppmLineLength = 70
+
+// Format to use for a file, from its extension: plain PPM for .ppm and PNG for
+// .png. Raw PPMs have the same extension as plain ones, so they have to be
+// asked for explicitly (see Encode)
+func FormatFromExtension(fileName string) (ImageFormat, error) {
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".ppm":
+		return PlainPPM, nil
+	case ".png":
+		return PNG, nil
+	default:
+		return 0, fmt.Errorf("unsupported image extension %q", ext)
+	}
+}
+
+// Encode writes the canvas to w in the given format
+func (c *Canvas) Encode(w io.Writer, format ImageFormat) error {
+	switch format {
+	case PlainPPM:
+		return c.EncodePlainPPM(w)
+	case RawPPM:
+		return c.EncodeRawPPM(w, 255)
+	case RawPPM16:
+		return c.EncodeRawPPM(w, 65535)
+	case PNG:
+		return c.EncodePNG(w)
+	default:
+		return fmt.Errorf("unsupported image format %d", format)
+	}
+}
+
+// WriteToFile writes the canvas to a file, in the format picked from the
+// extension of the file (see FormatFromExtension)
+func (c *Canvas) WriteToFile(fileName string) error {
+	format, err := FormatFromExtension(fileName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Encode(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Scale the channels of the color (clamped between 0 and 1) to 0..maxval
+func scaleColor(color core.Color, maxval int) (int, int, int) {
+	clamped := color.Clamp()
+	scale := func(v float64) int {
+		return int(math.Round(v * float64(maxval)))
+	}
+	return scale(clamped.R), scale(clamped.G), scale(clamped.B)
+}
+
+/*
+EncodePlainPPM writes the canvas as a plain PPM (P3), with a maxval of 255.
+
+Every row of pixels starts on a new line, and lines are wrapped so that none
+is longer than 70 characters.
+*/
+func (c *Canvas) EncodePlainPPM(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P3\n%d %d\n255\n", c.Width, c.Height)
+
+	for y := 0; y < c.Height; y++ {
+		lineLength := 0
+		for x := 0; x < c.Width; x++ {
+			r, g, b := scaleColor(c.Color[x][y], 255)
+			for _, v := range []int{r, g, b} {
+				value := strconv.Itoa(v)
+				// values are separated by a space, or by a new line when
+				// the value doesn't fit on the current line
+				if lineLength > 0 && lineLength+1+len(value) > ppmLineLength {
+					bw.WriteByte('\n')
+					lineLength = 0
+				} else if lineLength > 0 {
+					bw.WriteByte(' ')
+					lineLength++
+				}
+				bw.WriteString(value)
+				lineLength += len(value)
+			}
+		}
+		bw.WriteByte('\n')
+	}
+
+	// the bufio writer keeps the first error, and returns it on flush
+	return bw.Flush()
+}
+
+// EncodeRawPPM writes the canvas as a raw PPM (P6) with the given maxval (at
+// most 65535), using two bytes per value when maxval is above 255
+func (c *Canvas) EncodeRawPPM(w io.Writer, maxval int) error {
+	if maxval < 1 || maxval > 65535 {
+		return fmt.Errorf("invalid maxval %d, expected 1 to 65535", maxval)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P6\n%d %d\n%d\n", c.Width, c.Height, maxval)
+
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			r, g, b := scaleColor(c.Color[x][y], maxval)
+			for _, v := range []int{r, g, b} {
+				if maxval > 255 {
+					bw.WriteByte(byte(v >> 8))
+				}
+				bw.WriteByte(byte(v))
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// EncodePNG writes the canvas as an 8 bit PNG
+func (c *Canvas) EncodePNG(w io.Writer) error {
+	img := image.NewNRGBA(image.Rect(0, 0, c.Width, c.Height))
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			r, g, b := scaleColor(c.Color[x][y], 255)
+			img.SetNRGBA(x, y, imageColor.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255})
+		}
+	}
+
+	return png.Encode(w, img)
+}
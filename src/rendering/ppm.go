@@ -0,0 +1,241 @@
+package rendering
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	core "github.com/Naveenaidu/gray/src/core/color"
+)
+
+// Errors returned by LoadPPM, wrapped with the details of what went wrong
+// (and where), so they can be checked with errors.Is
+var (
+	ErrPPMHeader    = errors.New("malformed ppm header")
+	ErrPPMData      = errors.New("malformed ppm pixel data")
+	ErrPPMTruncated = errors.New("truncated ppm pixel data")
+)
+
+/*
+LoadPPM parses a PPM image, either plain (P3, ASCII) or raw (P6, binary), into
+a canvas.
+
+The values of the pixels are scaled by the maxval of the image, so that the
+colors are between 0 and 1 whatever the maxval is (raw images with a maxval
+above 255 use 2 bytes per value). Comments (from # to the end of the line) are
+allowed anywhere in the header, and in the pixel data of plain images.
+*/
+func LoadPPM(r io.Reader) (*Canvas, error) {
+	p := &ppmParser{r: bufio.NewReader(r)}
+
+	magic, offset, err := p.token()
+	if err != nil {
+		return nil, p.headerError(err, "magic number")
+	}
+	if magic != "P3" && magic != "P6" {
+		return nil, fmt.Errorf("%w: unsupported magic number %q at byte %d, expected P3 or P6", ErrPPMHeader, magic, offset)
+	}
+
+	width, err := p.headerValue("width", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	height, err := p.headerValue("height", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	maxval, err := p.headerValue("maxval", 65535)
+	if err != nil {
+		return nil, err
+	}
+
+	// the samples are read before allocating the canvas, so that a bogus
+	// header can't make us allocate a huge canvas
+	var samples []int
+	if magic == "P3" {
+		samples, err = p.plainSamples(width*height*3, maxval)
+	} else {
+		samples, err = p.rawSamples(width*height*3, maxval)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := NewCanvas(width, height, *core.Black)
+	scale := 1 / float64(maxval)
+	for i := 0; i < width*height; i++ {
+		r, g, b := samples[3*i], samples[3*i+1], samples[3*i+2]
+		color := core.NewColor(float64(r)*scale, float64(g)*scale, float64(b)*scale)
+		canvas.WritePixel(i%width, i/width, *color)
+	}
+
+	return canvas, nil
+}
+
+// Same as LoadPPM, reading the image from a file
+func LoadPPMFile(fileName string) (*Canvas, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadPPM(f)
+}
+
+type ppmParser struct {
+	r *bufio.Reader
+	// number of bytes read so far, to point at the problem in errors
+	offset int64
+}
+
+func (p *ppmParser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err == nil {
+		p.offset++
+	}
+	return b, err
+}
+
+func (p *ppmParser) unreadByte() {
+	p.r.UnreadByte()
+	p.offset--
+}
+
+func isPPMSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+/*
+Next token (a run of non whitespace characters), skipping whitespace and
+comments before it, along with the offset it starts at. The whitespace
+character right after the token is consumed, which matters for raw images
+where the pixel data starts right after a single whitespace character.
+
+Returns io.EOF if there is no token left.
+*/
+func (p *ppmParser) token() (string, int64, error) {
+	var b byte
+	var err error
+
+	// skip whitespace and comments
+	for {
+		if b, err = p.readByte(); err != nil {
+			return "", p.offset, err
+		}
+		if b == '#' {
+			for b != '\n' && b != '\r' {
+				if b, err = p.readByte(); err != nil {
+					return "", p.offset, err
+				}
+			}
+		} else if !isPPMSpace(b) {
+			break
+		}
+	}
+
+	start := p.offset - 1
+	token := []byte{b}
+	for {
+		if b, err = p.readByte(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", start, err
+		}
+		if isPPMSpace(b) {
+			break
+		}
+		if b == '#' {
+			// the comment is skipped by the next call
+			p.unreadByte()
+			break
+		}
+		token = append(token, b)
+	}
+
+	return string(token), start, nil
+}
+
+func (p *ppmParser) headerError(err error, what string) error {
+	if err == io.EOF {
+		return fmt.Errorf("%w: missing %s, unexpected end of file at byte %d", ErrPPMHeader, what, p.offset)
+	}
+	return err
+}
+
+// Next value of the header, which has to be an integer between 1 and max
+func (p *ppmParser) headerValue(what string, max int) (int, error) {
+	token, offset, err := p.token()
+	if err != nil {
+		return 0, p.headerError(err, what)
+	}
+
+	value, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s %q at byte %d", ErrPPMHeader, what, token, offset)
+	}
+	if value < 1 || value > max {
+		return 0, fmt.Errorf("%w: %s %d at byte %d is out of range, expected 1 to %d", ErrPPMHeader, what, value, offset, max)
+	}
+
+	return value, nil
+}
+
+// Pixel data of a plain image: ASCII values separated by whitespace
+func (p *ppmParser) plainSamples(count int, maxval int) ([]int, error) {
+	samples := []int{}
+	for len(samples) < count {
+		token, offset, err := p.token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: expected %d values, got %d", ErrPPMTruncated, count, len(samples))
+		} else if err != nil {
+			return nil, err
+		}
+
+		value, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid value %q at byte %d", ErrPPMData, token, offset)
+		}
+		if value < 0 || value > maxval {
+			return nil, fmt.Errorf("%w: value %d at byte %d is out of range, expected 0 to %d", ErrPPMData, value, offset, maxval)
+		}
+		samples = append(samples, value)
+	}
+
+	return samples, nil
+}
+
+// Pixel data of a raw image: one byte per value, or two (most significant
+// first) when maxval doesn't fit in a byte
+func (p *ppmParser) rawSamples(count int, maxval int) ([]int, error) {
+	size := 1
+	if maxval > 255 {
+		size = 2
+	}
+
+	samples := []int{}
+	for len(samples) < count {
+		offset := p.offset
+		value := 0
+		for i := 0; i < size; i++ {
+			b, err := p.readByte()
+			if err == io.EOF {
+				read := int64(len(samples)*size) + p.offset - offset
+				return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrPPMTruncated, count*size, read)
+			} else if err != nil {
+				return nil, err
+			}
+			value = value<<8 | int(b)
+		}
+
+		if value > maxval {
+			return nil, fmt.Errorf("%w: value %d at byte %d is out of range, expected 0 to %d", ErrPPMData, value, offset, maxval)
+		}
+		samples = append(samples, value)
+	}
+
+	return samples, nil
+}
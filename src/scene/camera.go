@@ -46,6 +46,8 @@ type Camera struct {
 	PixelSize  float64
 	HalfWidth  float64
 	halfHeight float64
+	// how many rays are shot through each pixel, and how they are combined
+	Sampling Sampling
 }
 
 func NewCamera(hsize int, vsize int, fieldOfView float64) *Camera {
@@ -78,9 +80,16 @@ func NewCamera(hsize int, vsize int, fieldOfView float64) *Camera {
 
 // Compute the world cooridates at the center of given pixel
 func RayForPixel(camera Camera, px int, py int) *rayt.Ray {
-	// offset from edge of canvas to the pixel center
-	xOffset := (float64(px) + 0.5) * camera.PixelSize
-	yOffset := (float64(py) + 0.5) * camera.PixelSize
+	return RayForPixelOffset(camera, px, py, 0.5, 0.5)
+}
+
+// Ray through the point (ox, oy) of the given pixel, where the offsets are
+// fractions of the pixel measured from its top left corner ((0.5, 0.5) is the
+// center of the pixel)
+func RayForPixelOffset(camera Camera, px int, py int, ox float64, oy float64) *rayt.Ray {
+	// offset from edge of canvas to the point in the pixel
+	xOffset := (float64(px) + ox) * camera.PixelSize
+	yOffset := (float64(py) + oy) * camera.PixelSize
 
 	// untransformed coordinates of pixel in the worl space
 	// (camera looks towards -z, so +x is to the left) i.e
@@ -105,13 +114,16 @@ func RayForPixel(camera Camera, px int, py int) *rayt.Ray {
 	return &rayt.Ray{Origin: *origin, Direction: *direction}
 }
 
+// Render the world as seen by the camera. The world is built (see World.Build)
+// first, so that it is up to date
 func Render(camera Camera, world World) *rendering.Canvas {
+	world.Build()
+
 	image := rendering.NewCanvas(camera.Hsize, camera.Vsize, *color.Black)
 
 	for y := 0; y < camera.Vsize; y++ {
 		for x := 0; x < camera.Hsize; x++ {
-			ray := RayForPixel(camera, x, y)
-			color := ColorAt(world, *ray)
+			color := ColorForPixel(camera, world, x, y)
 			image.WritePixel(x, y, color)
 		}
 	}
@@ -0,0 +1,207 @@
+package scene
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/shape"
+)
+
+/*
+OBJFile holds the geometry read from a Wavefront OBJ file.
+
+Only the statements needed for triangle meshes are understood:
+
+	v x y z        vertex
+	vn x y z       vertex normal
+	f v1 v2 v3 ... face, where each vertex is "v", "v/vt" or "v/vt/vn"
+	g name         all following faces belong to the named group
+
+Polygons with more than three vertices are split into a fan of triangles. When
+every vertex of a face has a normal, the face becomes a smooth triangle.
+
+Note that OBJ indices start at 1, while Vertices and Normals are 0-indexed.
+*/
+type OBJFile struct {
+	Vertices []math.Point
+	Normals  []math.Vector
+	// faces that appear before any "g" statement
+	DefaultGroup []shape.Shape
+	Groups       map[string][]shape.Shape
+	// line numbers (starting at 1) that were not understood or were malformed
+	IgnoredLines []int
+}
+
+// Parse an OBJ file. Unsupported or malformed lines do not stop the parsing,
+// they are reported in IgnoredLines. An error is only returned if reading fails
+func LoadOBJ(r io.Reader) (*OBJFile, error) {
+	obj := &OBJFile{
+		Vertices:     []math.Point{},
+		Normals:      []math.Vector{},
+		DefaultGroup: []shape.Shape{},
+		Groups:       map[string][]shape.Shape{},
+		IgnoredLines: []int{},
+	}
+	currentGroup := ""
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		ok := false
+		switch fields[0] {
+		case "v":
+			var p []float64
+			if p, ok = parseFloats(fields[1:]); ok {
+				obj.Vertices = append(obj.Vertices, *math.NewPoint(p[0], p[1], p[2]))
+			}
+		case "vn":
+			var n []float64
+			if n, ok = parseFloats(fields[1:]); ok {
+				obj.Normals = append(obj.Normals, *math.NewVector(n[0], n[1], n[2]))
+			}
+		case "f":
+			var triangles []shape.Shape
+			if triangles, ok = obj.parseFace(fields[1:]); ok {
+				if currentGroup == "" {
+					obj.DefaultGroup = append(obj.DefaultGroup, triangles...)
+				} else {
+					obj.Groups[currentGroup] = append(obj.Groups[currentGroup], triangles...)
+				}
+			}
+		case "g":
+			if len(fields) >= 2 {
+				currentGroup = strings.Join(fields[1:], " ")
+				ok = true
+			}
+		}
+
+		if !ok {
+			obj.IgnoredLines = append(obj.IgnoredLines, lineNum)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// Parse the x, y and z of a "v" or "vn" statement. A fourth (w) value is
+// allowed by the format and ignored
+func parseFloats(fields []string) ([]float64, bool) {
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, false
+	}
+
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
+
+// Convert the vertices of a face into triangles, using fan triangulation
+func (obj *OBJFile) parseFace(fields []string) ([]shape.Shape, bool) {
+	if len(fields) < 3 {
+		return nil, false
+	}
+
+	points := make([]math.Point, len(fields))
+	normals := make([]math.Vector, len(fields))
+	smooth := true
+
+	for i, f := range fields {
+		// each vertex is "v", "v/vt", "v//vn" or "v/vt/vn"
+		parts := strings.Split(f, "/")
+		if len(parts) > 3 {
+			return nil, false
+		}
+
+		vertex, ok := resolveIndex(parts[0], len(obj.Vertices))
+		if !ok {
+			return nil, false
+		}
+		points[i] = obj.Vertices[vertex]
+
+		if len(parts) == 3 && parts[2] != "" {
+			normal, ok := resolveIndex(parts[2], len(obj.Normals))
+			if !ok {
+				return nil, false
+			}
+			normals[i] = obj.Normals[normal]
+		} else {
+			smooth = false
+		}
+	}
+
+	triangles := []shape.Shape{}
+	for i := 1; i < len(points)-1; i++ {
+		if smooth {
+			triangles = append(triangles, shape.NewSmoothTriangle(
+				points[0], points[i], points[i+1],
+				normals[0], normals[i], normals[i+1],
+			))
+		} else {
+			triangles = append(triangles, shape.NewTriangle(points[0], points[i], points[i+1]))
+		}
+	}
+
+	return triangles, true
+}
+
+// Convert a 1-based (or negative, i.e relative to the end) OBJ index into a
+// 0-based index into a list of the given length
+func resolveIndex(s string, length int) (int, bool) {
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+
+	if index < 0 {
+		index = length + index
+	} else {
+		index = index - 1
+	}
+
+	if index < 0 || index >= length {
+		return 0, false
+	}
+	return index, true
+}
+
+// Convert the parsed file into a single group. Named groups become child
+// groups, while the faces of the default group are added directly
+func (obj *OBJFile) ToGroup() *shape.Group {
+	g := shape.NewGroup()
+	g.AddChild(obj.DefaultGroup...)
+
+	// sort the names so that the same file always gives the same group
+	names := make([]string, 0, len(obj.Groups))
+	for name := range obj.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := shape.NewGroup()
+		child.AddChild(obj.Groups[name]...)
+		g.AddChild(child)
+	}
+
+	return g
+}
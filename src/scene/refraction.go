@@ -0,0 +1,135 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	coreMath "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+	"github.com/Naveenaidu/gray/src/rayt"
+	"github.com/Naveenaidu/gray/src/shape"
+)
+
+/*
+Refractive indices of the materials on both sides of the surface at the hit:
+n1 is the material the ray is leaving and n2 the one it is entering.
+
+Walking through the intersections in order, we keep track of the objects the
+ray is inside of: the ray enters an object at its first intersection and
+leaves it at the next one. At the hit, n1 is the index of the innermost object
+the ray is in, and n2 the index of the innermost object after crossing the
+surface. Outside of every object, the ray travels through vacuum, and so it
+does inside objects whose material has no refractive index (0, e.g. materials
+built without DefaultMaterial).
+*/
+func refractiveIndices(hit rayt.Intersection, xs []rayt.Intersection) (float64, float64) {
+	n1, n2 := 1.0, 1.0
+	containers := []shape.Shape{}
+
+	for _, i := range xs {
+		if i == hit {
+			if len(containers) > 0 {
+				n1 = refractiveIndexOf(containers[len(containers)-1])
+			}
+		}
+
+		if idx := indexOfShape(containers, i.Object); idx >= 0 {
+			containers = append(containers[:idx], containers[idx+1:]...)
+		} else {
+			containers = append(containers, i.Object)
+		}
+
+		if i == hit {
+			if len(containers) > 0 {
+				n2 = refractiveIndexOf(containers[len(containers)-1])
+			}
+			break
+		}
+	}
+
+	return n1, n2
+}
+
+// Refractive index of the material of the shape, vacuum when it has none
+func refractiveIndexOf(s shape.Shape) float64 {
+	if index := s.GetMaterial().RefractiveIndex; index > 0 {
+		return index
+	}
+	return material.Vacuum
+}
+
+func indexOfShape(shapes []shape.Shape, s shape.Shape) int {
+	for i, candidate := range shapes {
+		if candidate == s {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+RefractedColor is the color seen through a transparent surface, scaled by how
+transparent the surface is.
+
+The direction of the refracted ray follows Snell's law. When the ray hits the
+surface at a steep enough angle while going into a less dense material (like
+from water into air), no light gets through at all: all of it is reflected,
+which is called total internal reflection. In that case (and for opaque
+surfaces, or once remaining drops to 0) the refracted color is black.
+*/
+func RefractedColor(world World, comps Computation, remaining int) color.Color {
+	transparency := comps.Object.GetMaterial().Transparency
+	if transparency == 0 || remaining <= 0 {
+		return *color.Black
+	}
+
+	// Snell's law: sin(theta_t) / sin(theta_i) = n1 / n2
+	nRatio := comps.N1 / comps.N2
+	cosI := comps.EyeV.DotProduct(comps.NormalV)
+	sin2T := nRatio * nRatio * (1 - cosI*cosI)
+	if sin2T > 1 {
+		// total internal reflection
+		return *color.Black
+	}
+
+	// direction = normalv * (n_ratio * cos_i - cos_t) - eyev * n_ratio
+	cosT := math.Sqrt(1 - sin2T)
+	direction := coreMath.SubtractVectors([]coreMath.Vector{
+		*comps.NormalV.ScalarMultiply(nRatio*cosI - cosT),
+		*comps.EyeV.ScalarMultiply(nRatio),
+	})
+
+	// start from the under point so that the refracted ray doesn't hit the
+	// surface it starts from
+	refractRay := rayt.Ray{Origin: comps.UnderPoint, Direction: *direction}
+	refractedColor := ColorAt(world, refractRay, remaining-1)
+
+	return *refractedColor.ScalarMultiply(transparency)
+}
+
+/*
+Schlick is an approximation of the Fresnel equations: the fraction of the light
+reflected by the surface at the hit (the rest is refracted).
+
+Transparent surfaces reflect more light the more grazing the angle at which
+they are seen, and reflect everything under total internal reflection.
+*/
+func Schlick(comps Computation) float64 {
+	// cosine of the angle between the eye and normal vectors
+	cos := comps.EyeV.DotProduct(comps.NormalV)
+
+	// total internal reflection can only occur if n1 > n2
+	if comps.N1 > comps.N2 {
+		n := comps.N1 / comps.N2
+		sin2T := n * n * (1 - cos*cos)
+		if sin2T > 1 {
+			return 1
+		}
+
+		// when n1 > n2, use cos(theta_t) instead
+		cos = math.Sqrt(1 - sin2T)
+	}
+
+	r0 := math.Pow((comps.N1-comps.N2)/(comps.N1+comps.N2), 2)
+	return r0 + (1-r0)*math.Pow(1-cos, 5)
+}
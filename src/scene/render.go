@@ -0,0 +1,183 @@
+package scene
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+	"github.com/Naveenaidu/gray/src/rendering"
+)
+
+// Tile size used when RenderOptions.TileSize is not set
+const DefaultTileSize = 16
+
+type RenderOptions struct {
+	// number of goroutines rendering tiles, defaults to the number of CPUs
+	Workers int
+	// width and height (in pixels) of the square tiles the canvas is split
+	// into, defaults to DefaultTileSize
+	TileSize int
+	// called after every finished tile, from the goroutine that called
+	// RenderContext (so never concurrently). Optional
+	Progress func(RenderProgress)
+}
+
+type RenderProgress struct {
+	TilesDone  int
+	TilesTotal int
+	Elapsed    time.Duration
+	// estimated time left, assuming the remaining tiles take as long as the
+	// finished ones did on average
+	ETA time.Duration
+}
+
+// rectangle of pixels, from (x0, y0) up to but excluding (x1, y1)
+type tile struct {
+	x0, y0 int
+	x1, y1 int
+}
+
+func (opts RenderOptions) workers() int {
+	if opts.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return opts.Workers
+}
+
+func (opts RenderOptions) tileSize() int {
+	if opts.TileSize <= 0 {
+		return DefaultTileSize
+	}
+	return opts.TileSize
+}
+
+// Split the canvas of the camera into tiles, row by row. Tiles on the right
+// and bottom edges are smaller when the canvas is not a multiple of the size
+func splitIntoTiles(camera Camera, size int) []tile {
+	tiles := []tile{}
+	for y := 0; y < camera.Vsize; y += size {
+		for x := 0; x < camera.Hsize; x += size {
+			tiles = append(tiles, tile{
+				x0: x,
+				y0: y,
+				x1: min(x+size, camera.Hsize),
+				y1: min(y+size, camera.Vsize),
+			})
+		}
+	}
+	return tiles
+}
+
+// Render the pixels of the tile, row by row. Returns false if the context was
+// cancelled before the tile was finished
+func renderTile(ctx context.Context, camera Camera, world World, image *rendering.Canvas, t tile) bool {
+	for y := t.y0; y < t.y1; y++ {
+		if ctx.Err() != nil {
+			return false
+		}
+		for x := t.x0; x < t.x1; x++ {
+			color := ColorForPixel(camera, world, x, y)
+			image.WritePixel(x, y, color)
+		}
+	}
+	return true
+}
+
+/*
+RenderParallel renders the world like Render, but splits the canvas into tiles
+that are rendered concurrently by a pool of workers.
+
+Every pixel is computed exactly as in Render, so the image is identical. The
+tiles never overlap, so each pixel of the canvas is written by exactly one
+worker and no locking is needed.
+
+The world is built (see World.Build) before the workers start, since building
+is not safe to do concurrently, and so that objects added (or children added
+to groups) since the world was last built are rendered. Do not change the
+world while rendering.
+*/
+func RenderParallel(camera Camera, world World, opts RenderOptions) *rendering.Canvas {
+	// the background context is never cancelled, so there is no error
+	image, _ := RenderContext(context.Background(), camera, world, opts)
+	return image
+}
+
+/*
+RenderContext is RenderParallel that can be stopped and monitored.
+
+When ctx is cancelled the workers stop after the row of pixels they are on,
+and the error of the context is returned along with the partially rendered
+canvas. Progress is reported through opts.Progress.
+*/
+func RenderContext(ctx context.Context, camera Camera, world World, opts RenderOptions) (*rendering.Canvas, error) {
+	start := time.Now()
+
+	world.Build()
+
+	image := rendering.NewCanvas(camera.Hsize, camera.Vsize, *color.Black)
+	tiles := splitIntoTiles(camera, opts.tileSize())
+
+	todo := make(chan tile)
+	// buffered, so that a worker never waits for the progress to be reported
+	finished := make(chan tile, len(tiles))
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range todo {
+				if !renderTile(ctx, camera, world, image, t) {
+					return
+				}
+				finished <- t
+			}
+		}()
+	}
+
+	go func() {
+		defer close(todo)
+		for _, t := range tiles {
+			select {
+			case todo <- t:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for done := 0; done < len(tiles); {
+		select {
+		case <-finished:
+			// both channels may be ready at once, don't report tiles
+			// after the render was cancelled
+			if ctx.Err() != nil {
+				continue
+			}
+			done++
+			if opts.Progress != nil {
+				opts.Progress(newRenderProgress(done, len(tiles), time.Since(start)))
+			}
+		case <-ctx.Done():
+			// wait for the workers, so that nothing writes to the canvas
+			// after it has been returned
+			wg.Wait()
+			return image, ctx.Err()
+		}
+	}
+
+	wg.Wait()
+	return image, nil
+}
+
+func newRenderProgress(done int, total int, elapsed time.Duration) RenderProgress {
+	perTile := elapsed / time.Duration(done)
+	return RenderProgress{
+		TilesDone:  done,
+		TilesTotal: total,
+		Elapsed:    elapsed,
+		ETA:        perTile * time.Duration(total-done),
+	}
+}
@@ -0,0 +1,157 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/Naveenaidu/gray/src/core/color"
+)
+
+// How the sample points are placed inside a pixel
+type SamplePattern int
+
+const (
+	// samples at the centers of the cells of an n x n grid over the pixel
+	RegularSampling SamplePattern = iota
+	// one sample at a random spot in each cell of an n x n grid (stratified)
+	JitteredSampling
+	// n x n samples at random spots anywhere in the pixel
+	RandomSampling
+)
+
+// Reconstruction filter, which weights the samples of a pixel by their distance
+// from the center of the pixel
+type Filter int
+
+const (
+	// every sample counts the same
+	BoxFilter Filter = iota
+	// weight falls linearly to zero at the edge of the pixel
+	TentFilter
+	// bell curve, cut off at the edge of the pixel
+	GaussianFilter
+	// Mitchell-Netravali cubic (B = C = 1/3), sharper than the gaussian
+	MitchellFilter
+)
+
+/*
+Sampling configures anti-aliasing: instead of a single ray through the center
+of each pixel, several rays are shot through the pixel and their colors are
+combined using the filter.
+
+The zero value shoots a single ray through the center, like RayForPixel.
+*/
+type Sampling struct {
+	// samples along each side of the pixel, i.e every pixel gets
+	// Samples * Samples rays. 0 and 1 both mean a single ray
+	Samples int
+	Pattern SamplePattern
+	Filter  Filter
+	// seed for the jittered and random patterns. Each pixel derives its own
+	// random numbers from the seed and its position, so renders are
+	// reproducible no matter in which order the pixels are rendered
+	Seed uint64
+}
+
+// Offsets (in [0, 1), relative to the top left corner of the pixel) of the
+// rays to shoot through the pixel (px, py)
+func (s Sampling) offsets(camera Camera, px int, py int) [][2]float64 {
+	n := s.Samples
+	if n <= 1 {
+		return [][2]float64{{0.5, 0.5}}
+	}
+
+	rng := rand.New(rand.NewPCG(s.Seed, uint64(py)*uint64(camera.Hsize)+uint64(px)))
+	cell := 1 / float64(n)
+	offsets := make([][2]float64, 0, n*n)
+
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			var ox, oy float64
+			switch s.Pattern {
+			case JitteredSampling:
+				ox = (float64(i) + rng.Float64()) * cell
+				oy = (float64(j) + rng.Float64()) * cell
+			case RandomSampling:
+				ox = rng.Float64()
+				oy = rng.Float64()
+			default:
+				ox = (float64(i) + 0.5) * cell
+				oy = (float64(j) + 0.5) * cell
+			}
+			offsets = append(offsets, [2]float64{ox, oy})
+		}
+	}
+
+	return offsets
+}
+
+// Weight of a sample at offset (ox, oy) in the pixel. The filters are
+// separable, i.e the weight is the product of the weights along x and y
+func (f Filter) weight(ox float64, oy float64) float64 {
+	// distance from the center, scaled so that the edge of the pixel is at 1
+	dx := math.Abs(ox-0.5) * 2
+	dy := math.Abs(oy-0.5) * 2
+	return f.weight1D(dx) * f.weight1D(dy)
+}
+
+func (f Filter) weight1D(d float64) float64 {
+	switch f {
+	case TentFilter:
+		return math.Max(0, 1-d)
+	case GaussianFilter:
+		// standard deviation of half the pixel; the value at the edge is
+		// subtracted so that the weight reaches zero there
+		return math.Max(0, math.Exp(-2*d*d)-math.Exp(-2))
+	case MitchellFilter:
+		// samples aren't shared with the neighbouring pixels, so only the
+		// positive lobe of the filter falls inside the pixel; squeezing the
+		// negative lobes in too lets a few samples outweigh the rest and
+		// pushes the color far outside of what the scene holds
+		return mitchell(d)
+	}
+	return 1
+}
+
+// Mitchell-Netravali filter for B = C = 1/3, defined over [0, 2)
+func mitchell(t float64) float64 {
+	const b, c = 1.0 / 3, 1.0 / 3
+
+	if t < 1 {
+		return ((12-9*b-6*c)*t*t*t + (-18+12*b+6*c)*t*t + (6 - 2*b)) / 6
+	} else if t < 2 {
+		return ((-b-6*c)*t*t*t + (6*b+30*c)*t*t + (-12*b-48*c)*t + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+/*
+ColorForPixel is the color of the pixel (px, py) of the camera's canvas.
+
+With the default sampling this is the color seen by the ray through the center
+of the pixel. Otherwise it is the filter-weighted average of the colors seen
+by all the sample rays.
+*/
+func ColorForPixel(camera Camera, world World, px int, py int) color.Color {
+	offsets := camera.Sampling.offsets(camera, px, py)
+	if len(offsets) == 1 {
+		return ColorAt(world, *RayForPixel(camera, px, py), MaxReflectionDepth)
+	}
+
+	sum := color.Black
+	totalWeight := 0.0
+	for _, o := range offsets {
+		w := camera.Sampling.Filter.weight(o[0], o[1])
+		if w == 0 {
+			continue
+		}
+		ray := RayForPixelOffset(camera, px, py, o[0], o[1])
+		sum = color.AddColors([]color.Color{*sum, *ColorAt(world, *ray, MaxReflectionDepth).ScalarMultiply(w)})
+		totalWeight += w
+	}
+
+	if totalWeight == 0 {
+		return *color.Black
+	}
+	return *sum.ScalarMultiply(1 / totalWeight)
+}
@@ -11,23 +11,43 @@ import (
 	"github.com/Naveenaidu/gray/src/shape"
 )
 
+// How many times a ray may bounce off reflective surfaces (or pass through
+// transparent ones) before giving up. Two mirrors facing each other would
+// otherwise reflect the ray forever
+const MaxReflectionDepth = 5
+
 type World struct {
-	Light   lighting.Light
-	Spheres []shape.Sphere
+	// every light adds its own diffuse and specular contribution, see ShadeHit
+	Lights  []lighting.Light
+	Objects []shape.Shape
+	// named sets of objects, that lights can be linked to
+	ObjectSets map[string][]shape.Shape
+	// lights that only light the objects of the named set they are linked
+	// to, see LinkLight
+	LightLinks map[lighting.Light]string
+	// hierarchy of the objects, nil until Build is called
+	bvh *shape.BVH
 }
 
 type Computation struct {
 	T         float64
-	Object    shape.Sphere
+	Object    shape.Shape
 	Point     math.Point
 	EyeV      math.Vector
 	NormalV   math.Vector
 	Inside    bool
 	OverPoint math.Point
+	// point just below the surface, where refracted rays start from
+	UnderPoint math.Point
+	ReflectV   math.Vector
+	// refractive indices of the materials the ray is leaving (N1) and
+	// entering (N2) at the hit
+	N1 float64
+	N2 float64
 }
 
 func DefaultWorld() *World {
-	pointLight := lighting.NewLight(*color.NewColor(1, 1, 1), *math.NewPoint(-10, 10, -10))
+	pointLight := lighting.NewPointLight(*color.NewColor(1, 1, 1), *math.NewPoint(-10, 10, -10))
 
 	s1 := shape.UnitSphere()
 	s1.Material = material.Material{
@@ -43,17 +63,37 @@ func DefaultWorld() *World {
 
 	// Two concentric spheres, where the outermost is a unit sphere and the
 	// innermost has a radius of 0.5
-	spheres := []shape.Sphere{*s1, *s2}
+	objects := []shape.Shape{s1, s2}
 
-	return &World{Light: pointLight, Spheres: spheres}
+	return &World{Lights: []lighting.Light{pointLight}, Objects: objects}
+}
+
+/*
+Build puts the objects of the world (and the children of every group in it)
+in a bounding volume hierarchy, so that a ray is only tested against the
+objects it might actually hit.
+
+Without it every ray is tested against every object. Build has to be called
+again after objects are added, removed or transformed. Render, RenderParallel
+and RenderContext always build the world themselves.
+*/
+func (w *World) Build() {
+	for _, obj := range w.Objects {
+		shape.BuildHierarchy(obj)
+	}
+	w.bvh = shape.NewBVH(w.Objects)
 }
 
 func IntersectWorld(world World, ray rayt.Ray) []rayt.Intersection {
 	xs := []rayt.Intersection{}
 
-	for _, s := range world.Spheres {
-		sIntersections := ray.IntersectSphere(s)
-		xs = append(xs, sIntersections...)
+	if world.bvh != nil {
+		xs = world.bvh.Intersect(ray.Origin, ray.Direction)
+	} else {
+		for _, s := range world.Objects {
+			sIntersections := ray.Intersect(s)
+			xs = append(xs, sIntersections...)
+		}
 	}
 
 	sort.Slice(xs, func(i, j int) bool {
@@ -64,39 +104,174 @@ func IntersectWorld(world World, ray rayt.Ray) []rayt.Intersection {
 
 }
 
-func PrepareComputations(intersection rayt.Intersection, ray rayt.Ray) *Computation {
+// Precompute the values needed to shade the intersection. xs is the sorted list
+// of all the intersections of the ray (including the hit), which is needed to
+// find out what materials the ray is travelling through at the hit
+func PrepareComputations(intersection rayt.Intersection, ray rayt.Ray, xs []rayt.Intersection) *Computation {
 	point := ray.Position(intersection.T)
 	eyev := ray.Direction.Negate()
-	normalV := lighting.NormalAt(intersection.Object, *point)
+	normalV := lighting.NormalAtHit(intersection, *point)
 	inside := false
 
-	// check if the ray is originating from inside of the sphere. If the eye
+	// check if the ray is originating from inside of the object. If the eye
 	// vector and the normal vector are in opposite direction than the ray is
-	// originating from inside of sphere
+	// originating from inside of object
 	if normalV.DotProduct(*eyev) < 0 {
 		inside = true
 		normalV = *normalV.Negate()
 	}
 
 	overPoint := point.AddVector(*normalV.ScalarMultiply(math.EPSILON))
+	underPoint := point.SubtractVector(*normalV.ScalarMultiply(math.EPSILON))
+	// direction in which the ray bounces off the surface
+	reflectV := lighting.Reflect(ray.Direction, normalV)
+
+	n1, n2 := refractiveIndices(intersection, xs)
 
 	return &Computation{
-		T:         intersection.T,
-		Object:    intersection.Object,
-		Point:     *point,
-		EyeV:      *eyev,
-		NormalV:   normalV,
-		Inside:    inside,
-		OverPoint: *overPoint,
+		T:          intersection.T,
+		Object:     intersection.Object,
+		Point:      *point,
+		EyeV:       *eyev,
+		NormalV:    normalV,
+		Inside:     inside,
+		OverPoint:  *overPoint,
+		UnderPoint: *underPoint,
+		ReflectV:   reflectV,
+		N1:         n1,
+		N2:         n2,
+	}
+}
+
+/*
+ShadeHit is the color at the hit described by comps. remaining is how many
+more times the ray may be reflected or refracted (see MaxReflectionDepth).
+
+Each light that is not blocked from the hit adds its diffuse and specular
+contribution. The ambient term is only added once (see AmbientIntensity), so
+adding lights doesn't brighten the shadows.
+
+Objects that don't receive shadows are lit as if nothing blocked the lights,
+and lights linked to a set of objects (see LinkLight) only light the objects of
+that set.
+*/
+func ShadeHit(world World, comps Computation, remaining int) color.Color {
+	m := comps.Object.GetMaterial()
+	surfaceColor := lighting.SurfaceColor(*m, comps.Object, comps.OverPoint)
+
+	// sample each light once, for its ambient term, its shadow and its
+	// lighting
+	lights := world.lightsOf(comps.Object)
+	samples := make([][]lighting.LightSample, len(lights))
+	for i, light := range lights {
+		samples[i] = light.SamplesAt(comps.OverPoint)
+	}
+
+	contributions := []color.Color{lighting.AmbientLighting(*m, surfaceColor, brightest(samples))}
+	receivesShadow := comps.Object.ReceivesShadow()
+	for _, lightSamples := range samples {
+		lightIntensity := 1.0
+		if receivesShadow {
+			lightIntensity = samplesIntensity(world, comps.OverPoint, lightSamples)
+		}
+		direct := lighting.DirectLighting(*m, surfaceColor, lightSamples, comps.EyeV, comps.NormalV, lightIntensity)
+		contributions = append(contributions, direct)
+	}
+	surface := *color.AddColors(contributions)
+
+	reflected := ReflectedColor(world, comps, remaining)
+	refracted := RefractedColor(world, comps, remaining)
+
+	// for surfaces that both reflect and refract (like glass), how much light
+	// is reflected depends on the angle at which the surface is seen
+	if m.Reflective > 0 && m.Transparency > 0 {
+		reflectance := Schlick(comps)
+		reflected = *reflected.ScalarMultiply(reflectance)
+		refracted = *refracted.ScalarMultiply(1 - reflectance)
+	}
+
+	return *color.AddColors([]color.Color{surface, reflected, refracted})
+}
+
+/*
+AmbientIntensity is the intensity of the ambient light of the world at the
+point on the object: the brightest of the lights that light the object (see
+Illuminates), channel by channel. The intensity of each light is the one that
+reaches the point (see lighting.SampledIntensity), so lights that fade with
+the distance, or spot lights pointing away, add little ambient light.
+
+With a single light this is the intensity of that light. Adding more lights
+(which are usually dimmer fill lights) leaves it unchanged, instead of adding
+up the ambient term of every light.
+*/
+func (w World) AmbientIntensity(object shape.Shape, point math.Point) color.Color {
+	lights := w.lightsOf(object)
+	samples := make([][]lighting.LightSample, len(lights))
+	for i, light := range lights {
+		samples[i] = light.SamplesAt(point)
+	}
+	return brightest(samples)
+}
+
+// Brightest of the sampled intensities of the lights, channel by channel
+func brightest(samples [][]lighting.LightSample) color.Color {
+	intensity := color.Color{}
+	for _, lightSamples := range samples {
+		lightIntensity := lighting.SampledIntensity(lightSamples)
+		intensity.R = max(intensity.R, lightIntensity.R)
+		intensity.G = max(intensity.G, lightIntensity.G)
+		intensity.B = max(intensity.B, lightIntensity.B)
 	}
+	return intensity
 }
 
-func ShadeHit(world World, comps Computation) color.Color {
-	inShadow := IsShadowed(world, comps.OverPoint)
-	return lighting.Lighting(comps.Object.Material, world.Light, comps.OverPoint, comps.EyeV, comps.NormalV, inShadow)
+// Lights of the world that light the object, see Illuminates
+func (w World) lightsOf(object shape.Shape) []lighting.Light {
+	lights := []lighting.Light{}
+	for _, light := range w.Lights {
+		if w.Illuminates(light, object) {
+			lights = append(lights, light)
+		}
+	}
+	return lights
 }
 
-func ColorAt(world World, ray rayt.Ray) color.Color {
+/*
+LinkLight restricts the light to the objects of the named set (see
+ObjectSets): the light only lights those objects, and the objects inside them
+when they are groups or CSGs. Objects outside of the set are not lit by the
+light at all (not even its ambient light), but they still cast shadows.
+
+Lights that are not linked light every object. The set can be filled (or
+changed) before or after linking the light.
+*/
+func (w *World) LinkLight(light lighting.Light, set string) {
+	if w.LightLinks == nil {
+		w.LightLinks = map[lighting.Light]string{}
+	}
+	w.LightLinks[light] = set
+}
+
+// Whether the light lights the object, see LinkLight
+func (w World) Illuminates(light lighting.Light, object shape.Shape) bool {
+	set, linked := w.LightLinks[light]
+	if !linked {
+		return true
+	}
+
+	for s := object; s != nil; s = s.GetParent() {
+		for _, member := range w.ObjectSets[set] {
+			if member == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Color seen by the ray, bouncing off (or through) at most remaining reflective
+// or transparent surfaces
+func ColorAt(world World, ray rayt.Ray, remaining int) color.Color {
 	color := color.Black
 	intrs := IntersectWorld(world, ray)
 
@@ -104,26 +279,82 @@ func ColorAt(world World, ray rayt.Ray) color.Color {
 	if hit == nil {
 		return *color
 	}
-	comps := PrepareComputations(*hit, ray)
-	hitColor := ShadeHit(world, *comps)
+	comps := PrepareComputations(*hit, ray, intrs)
+	hitColor := ShadeHit(world, *comps, remaining)
 
 	return hitColor
 
 }
 
-func IsShadowed(world World, point math.Point) bool {
-	v := world.Light.Position.Subtract(point)
-	distance := v.Magnitude()
-	direction := v.Normalize()
+/*
+ReflectedColor is the color seen in the direction the ray bounces off the
+surface, scaled by how reflective the surface is.
 
+Non-reflective surfaces reflect black, and so does every surface once
+remaining drops to 0, which stops the recursion between facing mirrors.
+*/
+func ReflectedColor(world World, comps Computation, remaining int) color.Color {
+	reflective := comps.Object.GetMaterial().Reflective
+	if reflective == 0 || remaining <= 0 {
+		return *color.Black
+	}
+
+	// start from the over point so that the reflected ray doesn't hit the
+	// surface it starts from
+	reflectRay := rayt.Ray{Origin: comps.OverPoint, Direction: comps.ReflectV}
+	reflectedColor := ColorAt(world, reflectRay, remaining-1)
+
+	return *reflectedColor.ScalarMultiply(reflective)
+}
+
+/*
+IsShadowed is how much of the light reaches the point, given the objects of
+the world that may be in the way: 1 when the point sees the whole light, 0
+when it is fully in shadow. This is the lightIntensity expected by
+lighting.Lighting.
+
+A shadow ray is cast towards every sample of the light (see
+Light.SamplesAt), up to the distance of the sample. Point lights either reach
+the point or not, while for area lights this is the fraction of the samples
+that are visible from the point, which gives soft shadows. Directional lights
+are infinitely far away, so anything along their direction casts a shadow.
+
+Objects that don't cast shadows (see Shape.CastsShadow) let the light through.
+*/
+func IsShadowed(world World, light lighting.Light, point math.Point) float64 {
+	return samplesIntensity(world, point, light.SamplesAt(point))
+}
+
+// Fraction of the samples of a light that reach the point, see IsShadowed
+func samplesIntensity(world World, point math.Point, samples []lighting.LightSample) float64 {
+	visible := 0
+	for _, sample := range samples {
+		if !isBlocked(world, point, sample.Direction, sample.Distance) {
+			visible++
+		}
+	}
+	return float64(visible) / float64(len(samples))
+}
+
+// Whether an object of the world is between the point and the light, distance
+// away in the given (normalized) direction
+func isBlocked(world World, point math.Point, direction math.Vector, distance float64) bool {
 	// Shadow ray (light - point)
-	shadowRay := rayt.Ray{Origin: point, Direction: *direction}
-	// shadow ray and the intersection of that ray with world
+	shadowRay := rayt.Ray{Origin: point, Direction: direction}
+	// shadow ray and the intersection of that ray with world, sorted by t
 	intersections := IntersectWorld(world, shadowRay)
 
-	h := shadowRay.Hit(intersections)
-	if h != nil && h.T < distance {
-		return true
+	for _, i := range intersections {
+		// behind the point, or beyond the light
+		if i.T <= 0 {
+			continue
+		}
+		if i.T >= distance {
+			return false
+		}
+		if i.Object.CastsShadow() {
+			return true
+		}
 	}
 	return false
 
@@ -0,0 +1,163 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Axis aligned bounding box, described by its minimum and maximum corners
+type BoundingBox struct {
+	Min core.Point
+	Max core.Point
+}
+
+func NewBoundingBox(min core.Point, max core.Point) *BoundingBox {
+	return &BoundingBox{Min: min, Max: max}
+}
+
+/*
+Slab method of intersecting a ray with an axis aligned box.
+
+Each pair of parallel faces of the box is treated as a "slab", i.e the region
+between two parallel planes. The ray enters and leaves each slab at some t;
+the ray is inside the box only while it is inside all three slabs, so:
+
+  - tmin is the largest of the three entering t values
+  - tmax is the smallest of the three leaving t values
+
+If tmin > tmax the ray missed the box. The returned values are only meaningful
+when hit is true.
+*/
+func (b BoundingBox) Slab(origin core.Point, direction core.Vector) (tmin float64, tmax float64, hit bool) {
+	xtmin, xtmax := checkAxis(origin.X, direction.X, b.Min.X, b.Max.X)
+	ytmin, ytmax := checkAxis(origin.Y, direction.Y, b.Min.Y, b.Max.Y)
+	ztmin, ztmax := checkAxis(origin.Z, direction.Z, b.Min.Z, b.Max.Z)
+
+	tmin = math.Max(xtmin, math.Max(ytmin, ztmin))
+	tmax = math.Min(xtmax, math.Min(ytmax, ztmax))
+
+	return tmin, tmax, tmin <= tmax
+}
+
+// Whether the ray passes through the box at all
+func (b BoundingBox) Intersects(origin core.Point, direction core.Vector) bool {
+	_, _, hit := b.Slab(origin, direction)
+	return hit
+}
+
+// t values at which a ray enters and leaves the slab [min, max] along one axis
+func checkAxis(origin float64, direction float64, min float64, max float64) (float64, float64) {
+	tminNumerator := min - origin
+	tmaxNumerator := max - origin
+
+	var tmin, tmax float64
+	// a ray parallel to the slab never crosses its planes, so the t values
+	// are pushed to infinity (with the sign telling which side the ray is on)
+	if math.Abs(direction) >= core.EPSILON {
+		tmin = tminNumerator / direction
+		tmax = tmaxNumerator / direction
+	} else {
+		tmin = tminNumerator * math.Inf(1)
+		tmax = tmaxNumerator * math.Inf(1)
+	}
+
+	if tmin > tmax {
+		tmin, tmax = tmax, tmin
+	}
+
+	return tmin, tmax
+}
+
+// A box that contains nothing. Adding points or boxes to it grows it to fit
+func EmptyBoundingBox() *BoundingBox {
+	return &BoundingBox{
+		Min: *core.NewPoint(math.Inf(1), math.Inf(1), math.Inf(1)),
+		Max: *core.NewPoint(math.Inf(-1), math.Inf(-1), math.Inf(-1)),
+	}
+}
+
+// A box that contains everything, used for shapes like planes that have no end
+func InfiniteBoundingBox() *BoundingBox {
+	return &BoundingBox{
+		Min: *core.NewPoint(math.Inf(-1), math.Inf(-1), math.Inf(-1)),
+		Max: *core.NewPoint(math.Inf(1), math.Inf(1), math.Inf(1)),
+	}
+}
+
+// Grow the box so that it contains the point
+func (b *BoundingBox) AddPoint(p core.Point) {
+	b.Min = *core.NewPoint(math.Min(b.Min.X, p.X), math.Min(b.Min.Y, p.Y), math.Min(b.Min.Z, p.Z))
+	b.Max = *core.NewPoint(math.Max(b.Max.X, p.X), math.Max(b.Max.Y, p.Y), math.Max(b.Max.Z, p.Z))
+}
+
+// Grow the box so that it contains another box. Merging an empty box leaves
+// the box unchanged
+func (b *BoundingBox) Merge(other BoundingBox) {
+	if other.IsEmpty() {
+		return
+	}
+	b.AddPoint(other.Min)
+	b.AddPoint(other.Max)
+}
+
+// Whether the box contains nothing at all, like the bounds of an empty group
+func (b BoundingBox) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+// Whether the box extends infinitely along any axis
+func (b BoundingBox) IsInfinite() bool {
+	if b.IsEmpty() {
+		return false
+	}
+
+	for _, v := range []float64{b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z} {
+		if math.IsInf(v, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b BoundingBox) Centroid() core.Point {
+	return *core.NewPoint(
+		(b.Min.X+b.Max.X)/2,
+		(b.Min.Y+b.Max.Y)/2,
+		(b.Min.Z+b.Max.Z)/2,
+	)
+}
+
+/*
+Transform the box, and return the axis aligned box that contains the result.
+
+All eight corners are transformed, since a rotated box is no longer axis
+aligned. Boxes that are infinite stay infinite, as transforming infinity would
+otherwise give NaN (inf * 0) values.
+*/
+func (b BoundingBox) Transform(m core.Matrix) BoundingBox {
+	if b.IsEmpty() {
+		return *EmptyBoundingBox()
+	}
+	if b.IsInfinite() {
+		return *InfiniteBoundingBox()
+	}
+
+	transformed := EmptyBoundingBox()
+	for _, x := range []float64{b.Min.X, b.Max.X} {
+		for _, y := range []float64{b.Min.Y, b.Max.Y} {
+			for _, z := range []float64{b.Min.Z, b.Max.Z} {
+				corner := m.Multiply(*core.NewPoint(x, y, z).ToMatrix()).ToPoint()
+				transformed.AddPoint(*corner)
+			}
+		}
+	}
+
+	return *transformed
+}
+
+// Bounds of a shape in the space of its parent, i.e the object space bounds
+// transformed by the shape's own transform
+func BoundsOf(s Shape) BoundingBox {
+	return s.Bounds().Transform(s.GetTransform())
+}
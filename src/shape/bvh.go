@@ -0,0 +1,173 @@
+package shape
+
+import (
+	"sort"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+)
+
+// Maximum number of shapes in a leaf of the hierarchy. Below this, testing
+// every shape is cheaper than testing more boxes
+const bvhLeafSize = 4
+
+/*
+BVH (bounding volume hierarchy) speeds up intersecting a ray with many shapes.
+
+The shapes are put in a binary tree of bounding boxes: each node's box holds
+all the shapes below it, so if a ray misses a node's box, none of the shapes
+below it need to be tested. Nodes are split at the median of the shapes'
+centers along the longest axis, which keeps the tree balanced.
+
+Shapes with infinite bounds (like planes) can not be placed in a box, they are
+kept aside and tested for every ray.
+
+The shapes are expected to share the same parent space (e.g the objects of a
+world, or the children of a group). The hierarchy is a snapshot: build a new
+one if the shapes or their transforms change.
+*/
+type BVH struct {
+	root      *bvhNode
+	unbounded []Shape
+}
+
+type bvhNode struct {
+	bounds BoundingBox
+	left   *bvhNode
+	right  *bvhNode
+	// only set for leaves
+	shapes []Shape
+}
+
+// shape along with its bounds in parent space, so that they are only
+// computed once while building
+type boundedShape struct {
+	shape    Shape
+	bounds   BoundingBox
+	centroid core.Point
+}
+
+func NewBVH(shapes []Shape) *BVH {
+	bvh := &BVH{unbounded: []Shape{}}
+	bounded := []boundedShape{}
+
+	for _, s := range shapes {
+		bounds := BoundsOf(s)
+		// nothing to hit in an empty shape (e.g an empty group)
+		if bounds.IsEmpty() {
+			continue
+		}
+		if bounds.IsInfinite() {
+			bvh.unbounded = append(bvh.unbounded, s)
+			continue
+		}
+		bounded = append(bounded, boundedShape{s, bounds, bounds.Centroid()})
+	}
+
+	if len(bounded) > 0 {
+		bvh.root = buildBVHNode(bounded)
+	}
+
+	return bvh
+}
+
+func buildBVHNode(shapes []boundedShape) *bvhNode {
+	node := &bvhNode{bounds: *EmptyBoundingBox()}
+	centroids := EmptyBoundingBox()
+	for _, s := range shapes {
+		node.bounds.Merge(s.bounds)
+		centroids.AddPoint(s.centroid)
+	}
+
+	// split along the axis in which the centers are spread the most
+	spread := centroids.Max.Subtract(centroids.Min)
+	axis := func(p core.Point) float64 { return p.X }
+	largest := spread.X
+	if spread.Y > largest {
+		axis = func(p core.Point) float64 { return p.Y }
+		largest = spread.Y
+	}
+	if spread.Z > largest {
+		axis = func(p core.Point) float64 { return p.Z }
+		largest = spread.Z
+	}
+
+	// small nodes, or nodes where every shape has the same center (and
+	// hence can not be split), become leaves
+	if len(shapes) <= bvhLeafSize || largest <= 0 {
+		node.shapes = make([]Shape, len(shapes))
+		for i, s := range shapes {
+			node.shapes[i] = s.shape
+		}
+		return node
+	}
+
+	sort.Slice(shapes, func(i, j int) bool {
+		return axis(shapes[i].centroid) < axis(shapes[j].centroid)
+	})
+
+	mid := len(shapes) / 2
+	node.left = buildBVHNode(shapes[:mid])
+	node.right = buildBVHNode(shapes[mid:])
+	return node
+}
+
+// Build the bounding volume hierarchies of every group in (or being) s. See
+// Group.Build
+func BuildHierarchy(s Shape) {
+	switch container := s.(type) {
+	case *Group:
+		container.Build()
+	case *CSG:
+		BuildHierarchy(container.Left)
+		BuildHierarchy(container.Right)
+	}
+}
+
+// Bounds of everything in the hierarchy
+func (bvh *BVH) Bounds() BoundingBox {
+	if len(bvh.unbounded) > 0 {
+		return *InfiniteBoundingBox()
+	}
+	if bvh.root == nil {
+		return *EmptyBoundingBox()
+	}
+	return bvh.root.bounds
+}
+
+// Intersect a ray, given in the parent space of the shapes, with the shapes
+// in the hierarchy. The intersections are not sorted
+func (bvh *BVH) Intersect(origin core.Point, direction core.Vector) []Intersection {
+	xs := []Intersection{}
+
+	for _, s := range bvh.unbounded {
+		xs = append(xs, intersectChild(s, origin, direction)...)
+	}
+
+	if bvh.root != nil {
+		xs = bvh.root.intersect(origin, direction, xs)
+	}
+
+	return xs
+}
+
+func (node *bvhNode) intersect(origin core.Point, direction core.Vector, xs []Intersection) []Intersection {
+	if !node.bounds.Intersects(origin, direction) {
+		return xs
+	}
+
+	if node.shapes != nil {
+		for _, s := range node.shapes {
+			xs = append(xs, intersectChild(s, origin, direction)...)
+		}
+		return xs
+	}
+
+	xs = node.left.intersect(origin, direction, xs)
+	return node.right.intersect(origin, direction, xs)
+}
+
+// Intersect a ray given in the parent space of s with s
+func intersectChild(s Shape, origin core.Point, direction core.Vector) []Intersection {
+	objectOrigin, objectDirection := toObjectSpace(s, origin, direction)
+	return s.LocalIntersect(objectOrigin, objectDirection)
+}
@@ -0,0 +1,141 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+/*
+Double napped cone centered on the y axis, in object space: two cones placed
+tip to tip at the origin. The radius of the cone at any height y is |y|.
+
+Like the cylinder, Minimum and Maximum truncate the cone along the y axis and
+Closed adds end caps at those limits.
+*/
+type Cone struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	Minimum   float64
+	Maximum   float64
+	Closed    bool
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewCone() *Cone {
+	return &Cone{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		Minimum:   math.Inf(-1),
+		Maximum:   math.Inf(1),
+		Closed:    false,
+	}
+}
+
+func (cone *Cone) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	xs := []float64{}
+
+	a := direction.X*direction.X - direction.Y*direction.Y + direction.Z*direction.Z
+	b := 2*origin.X*direction.X - 2*origin.Y*direction.Y + 2*origin.Z*direction.Z
+	c := origin.X*origin.X - origin.Y*origin.Y + origin.Z*origin.Z
+
+	if core.IsFloatEqual(a, 0) {
+		// the ray is parallel to one of the cone's halves, so it can hit
+		// the other half only once. If b is zero as well the ray misses
+		if !core.IsFloatEqual(b, 0) {
+			t := -c / (2 * b)
+			xs = appendIfWithin(xs, t, origin.Y+t*direction.Y, cone.Minimum, cone.Maximum)
+		}
+	} else {
+		discriminant := b*b - 4*a*c
+		// ray does not intersect the cone
+		if discriminant < 0 {
+			return []Intersection{}
+		}
+
+		t0 := (-b - math.Sqrt(discriminant)) / (2 * a)
+		t1 := (-b + math.Sqrt(discriminant)) / (2 * a)
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		xs = appendIfWithin(xs, t0, origin.Y+t0*direction.Y, cone.Minimum, cone.Maximum)
+		xs = appendIfWithin(xs, t1, origin.Y+t1*direction.Y, cone.Minimum, cone.Maximum)
+	}
+
+	return intersectionsOf(cone, cone.intersectCaps(origin, direction, xs)...)
+}
+
+// caps of a closed cone are discs whose radius is the absolute value of the y
+// limit they sit at
+func (cone *Cone) intersectCaps(origin core.Point, direction core.Vector, xs []float64) []float64 {
+	if !cone.Closed || core.IsFloatEqual(direction.Y, 0) {
+		return xs
+	}
+
+	t := (cone.Minimum - origin.Y) / direction.Y
+	if checkCap(origin, direction, t, math.Abs(cone.Minimum)) {
+		xs = append(xs, t)
+	}
+
+	t = (cone.Maximum - origin.Y) / direction.Y
+	if checkCap(origin, direction, t, math.Abs(cone.Maximum)) {
+		xs = append(xs, t)
+	}
+
+	return xs
+}
+
+func (cone *Cone) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	dist := p.X*p.X + p.Z*p.Z
+
+	if dist < cone.Maximum*cone.Maximum && p.Y >= cone.Maximum-core.EPSILON {
+		return *core.NewVector(0, 1, 0)
+	} else if dist < cone.Minimum*cone.Minimum && p.Y <= cone.Minimum+core.EPSILON {
+		return *core.NewVector(0, -1, 0)
+	}
+
+	y := math.Sqrt(dist)
+	if p.Y > 0 {
+		y = -y
+	}
+
+	return *core.NewVector(p.X, y, p.Z)
+}
+
+func (cone *Cone) GetTransform() core.Matrix {
+	return cone.Transform
+}
+
+// The widest part of the cone is at whichever limit is farthest from origin
+func (cone *Cone) Bounds() BoundingBox {
+	radius := math.Max(math.Abs(cone.Minimum), math.Abs(cone.Maximum))
+	return *NewBoundingBox(
+		*core.NewPoint(-radius, cone.Minimum, -radius),
+		*core.NewPoint(radius, cone.Maximum, radius),
+	)
+}
+
+func (cone *Cone) GetMaterial() *material.Material {
+	return &cone.Material
+}
+
+func (cone *Cone) GetParent() Shape {
+	return cone.Parent
+}
+
+func (cone *Cone) SetParent(parent Shape) {
+	cone.Parent = parent
+}
+
+func (cone *Cone) CastsShadow() bool {
+	return castsShadow(cone.NoCastShadow, cone.Parent)
+}
+
+func (cone *Cone) ReceivesShadow() bool {
+	return receivesShadow(cone.NoReceiveShadow, cone.Parent)
+}
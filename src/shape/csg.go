@@ -0,0 +1,170 @@
+package shape
+
+import (
+	"sort"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+type CSGOperation int
+
+const (
+	// everything inside either of the shapes
+	CSGUnion CSGOperation = iota
+	// only what is inside both shapes
+	CSGIntersection
+	// what is inside the left shape but not inside the right one
+	CSGDifference
+)
+
+/*
+CSG (constructive solid geometry) combines two shapes into a new one with a
+set operation, e.g a cube with a hole drilled into it is the difference of a
+cube and a cylinder.
+
+Like a group, the CSG has no surface of its own and its transform applies to
+both of its children.
+*/
+type CSG struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	Operation CSGOperation
+	Left      Shape
+	Right     Shape
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewCSG(operation CSGOperation, left Shape, right Shape) *CSG {
+	csg := &CSG{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		Operation: operation,
+		Left:      left,
+		Right:     right,
+	}
+	left.SetParent(csg)
+	right.SetParent(csg)
+	return csg
+}
+
+/*
+Whether an intersection is part of the surface of the combined shape.
+
+  - leftHit: the intersection is with the left shape (otherwise the right)
+  - inLeft: the intersection is inside the left shape
+  - inRight: the intersection is inside the right shape
+*/
+func IntersectionAllowed(op CSGOperation, leftHit bool, inLeft bool, inRight bool) bool {
+	switch op {
+	case CSGUnion:
+		// keep hits on either shape which are not inside the other one
+		return (leftHit && !inRight) || (!leftHit && !inLeft)
+	case CSGIntersection:
+		// keep hits on either shape which are inside the other one
+		return (leftHit && inRight) || (!leftHit && inLeft)
+	case CSGDifference:
+		// keep hits on the left shape which are not inside the right one, and
+		// hits on the right shape which are inside the left one
+		return (leftHit && !inRight) || (!leftHit && inLeft)
+	}
+	return false
+}
+
+/*
+Keep only the intersections that are on the surface of the combined shape.
+
+The intersections must be sorted by t. Walking along the ray, every hit on a
+child toggles whether the ray is inside that child; that (along with the
+operation) decides whether the hit is kept.
+*/
+func (csg *CSG) FilterIntersections(xs []Intersection) []Intersection {
+	// the ray starts outside of both children
+	inLeft := false
+	inRight := false
+
+	result := []Intersection{}
+	for _, i := range xs {
+		leftHit := includes(csg.Left, i.Object)
+
+		if IntersectionAllowed(csg.Operation, leftHit, inLeft, inRight) {
+			result = append(result, i)
+		}
+
+		if leftHit {
+			inLeft = !inLeft
+		} else {
+			inRight = !inRight
+		}
+	}
+
+	return result
+}
+
+func (csg *CSG) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	xs := intersectChild(csg.Left, origin, direction)
+	xs = append(xs, intersectChild(csg.Right, origin, direction)...)
+
+	sort.Slice(xs, func(i, j int) bool {
+		return xs[i].T < xs[j].T
+	})
+
+	return csg.FilterIntersections(xs)
+}
+
+// Intersections always refer to the primitives inside the CSG, so the normal
+// of a CSG itself is never needed
+func (csg *CSG) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	panic("shape: LocalNormalAt called on a CSG, normals come from its children")
+}
+
+func (csg *CSG) GetTransform() core.Matrix {
+	return csg.Transform
+}
+
+// The combined shape never extends beyond its children, so the box containing
+// both of them is always big enough
+func (csg *CSG) Bounds() BoundingBox {
+	bounds := BoundsOf(csg.Left)
+	bounds.Merge(BoundsOf(csg.Right))
+	return bounds
+}
+
+func (csg *CSG) GetMaterial() *material.Material {
+	return &csg.Material
+}
+
+func (csg *CSG) GetParent() Shape {
+	return csg.Parent
+}
+
+func (csg *CSG) SetParent(parent Shape) {
+	csg.Parent = parent
+}
+
+func (csg *CSG) CastsShadow() bool {
+	return castsShadow(csg.NoCastShadow, csg.Parent)
+}
+
+func (csg *CSG) ReceivesShadow() bool {
+	return receivesShadow(csg.NoReceiveShadow, csg.Parent)
+}
+
+// Whether obj is s, or is somewhere inside s (for groups and CSGs)
+func includes(s Shape, obj Shape) bool {
+	switch container := s.(type) {
+	case *Group:
+		for _, child := range container.Children {
+			if includes(child, obj) {
+				return true
+			}
+		}
+		return false
+	case *CSG:
+		return includes(container.Left, obj) || includes(container.Right, obj)
+	}
+	return s == obj
+}
@@ -0,0 +1,82 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+// Cube is an axis aligned box, which in object space extends from -1 to 1 on
+// every axis. Scale and translate it to get boxes of any size.
+type Cube struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewCube() *Cube {
+	return &Cube{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+	}
+}
+
+// Object space bounds of every cube
+var unitCubeBounds = BoundingBox{
+	Min: *core.NewPoint(-1, -1, -1),
+	Max: *core.NewPoint(1, 1, 1),
+}
+
+func (c *Cube) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	tmin, tmax, hit := unitCubeBounds.Slab(origin, direction)
+	if !hit {
+		return []Intersection{}
+	}
+
+	return intersectionsOf(c, tmin, tmax)
+}
+
+// The normal is the one of the face the point lies on, i.e the axis with the
+// largest absolute component
+func (c *Cube) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	maxc := math.Max(math.Abs(p.X), math.Max(math.Abs(p.Y), math.Abs(p.Z)))
+
+	if maxc == math.Abs(p.X) {
+		return *core.NewVector(p.X, 0, 0)
+	} else if maxc == math.Abs(p.Y) {
+		return *core.NewVector(0, p.Y, 0)
+	}
+	return *core.NewVector(0, 0, p.Z)
+}
+
+func (c *Cube) GetTransform() core.Matrix {
+	return c.Transform
+}
+
+func (c *Cube) Bounds() BoundingBox {
+	return unitCubeBounds
+}
+
+func (c *Cube) GetMaterial() *material.Material {
+	return &c.Material
+}
+
+func (c *Cube) GetParent() Shape {
+	return c.Parent
+}
+
+func (c *Cube) SetParent(parent Shape) {
+	c.Parent = parent
+}
+
+func (c *Cube) CastsShadow() bool {
+	return castsShadow(c.NoCastShadow, c.Parent)
+}
+
+func (c *Cube) ReceivesShadow() bool {
+	return receivesShadow(c.NoReceiveShadow, c.Parent)
+}
@@ -0,0 +1,154 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+/*
+Cylinder of radius 1 centered on the y axis, in object space.
+
+By default the cylinder is infinitely long. Minimum and Maximum truncate it
+along the y axis (the limits themselves are excluded), and Closed adds the end
+caps at those limits so the cylinder looks solid instead of like a pipe.
+*/
+type Cylinder struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	Minimum   float64
+	Maximum   float64
+	Closed    bool
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewCylinder() *Cylinder {
+	return &Cylinder{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		Minimum:   math.Inf(-1),
+		Maximum:   math.Inf(1),
+		Closed:    false,
+	}
+}
+
+func (cyl *Cylinder) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	xs := []float64{}
+
+	a := direction.X*direction.X + direction.Z*direction.Z
+
+	// a ray parallel to the y axis can only hit the caps
+	if !core.IsFloatEqual(a, 0) {
+		b := 2*origin.X*direction.X + 2*origin.Z*direction.Z
+		c := origin.X*origin.X + origin.Z*origin.Z - 1
+
+		discriminant := b*b - 4*a*c
+		// ray does not intersect the cylinder
+		if discriminant < 0 {
+			return []Intersection{}
+		}
+
+		t0 := (-b - math.Sqrt(discriminant)) / (2 * a)
+		t1 := (-b + math.Sqrt(discriminant)) / (2 * a)
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		// only keep the intersections between the truncation limits
+		xs = appendIfWithin(xs, t0, origin.Y+t0*direction.Y, cyl.Minimum, cyl.Maximum)
+		xs = appendIfWithin(xs, t1, origin.Y+t1*direction.Y, cyl.Minimum, cyl.Maximum)
+	}
+
+	return intersectionsOf(cyl, cyl.intersectCaps(origin, direction, xs)...)
+}
+
+// caps of a closed cylinder are discs of radius 1 at y = minimum and maximum
+func (cyl *Cylinder) intersectCaps(origin core.Point, direction core.Vector, xs []float64) []float64 {
+	// caps only matter if the cylinder is closed, and might possibly be
+	// intersected by the ray
+	if !cyl.Closed || core.IsFloatEqual(direction.Y, 0) {
+		return xs
+	}
+
+	// check for an intersection with the lower end cap by intersecting
+	// the ray with the plane at y=cyl.minimum
+	t := (cyl.Minimum - origin.Y) / direction.Y
+	if checkCap(origin, direction, t, 1) {
+		xs = append(xs, t)
+	}
+
+	// check for an intersection with the upper end cap by intersecting
+	// the ray with the plane at y=cyl.maximum
+	t = (cyl.Maximum - origin.Y) / direction.Y
+	if checkCap(origin, direction, t, 1) {
+		xs = append(xs, t)
+	}
+
+	return xs
+}
+
+func (cyl *Cylinder) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	// square of the distance from the y axis
+	dist := p.X*p.X + p.Z*p.Z
+
+	// points on the caps are within the radius and at the limits
+	if dist < 1 && p.Y >= cyl.Maximum-core.EPSILON {
+		return *core.NewVector(0, 1, 0)
+	} else if dist < 1 && p.Y <= cyl.Minimum+core.EPSILON {
+		return *core.NewVector(0, -1, 0)
+	}
+
+	return *core.NewVector(p.X, 0, p.Z)
+}
+
+func (cyl *Cylinder) GetTransform() core.Matrix {
+	return cyl.Transform
+}
+
+func (cyl *Cylinder) Bounds() BoundingBox {
+	return *NewBoundingBox(
+		*core.NewPoint(-1, cyl.Minimum, -1),
+		*core.NewPoint(1, cyl.Maximum, 1),
+	)
+}
+
+func (cyl *Cylinder) GetMaterial() *material.Material {
+	return &cyl.Material
+}
+
+// Append t to xs, if y (the height at which the ray is at t) lies strictly
+// between minimum and maximum
+func appendIfWithin(xs []float64, t float64, y float64, minimum float64, maximum float64) []float64 {
+	if minimum < y && y < maximum {
+		return append(xs, t)
+	}
+	return xs
+}
+
+// Checks if the intersection at t is within the given radius from the y axis
+func checkCap(origin core.Point, direction core.Vector, t float64, radius float64) bool {
+	x := origin.X + t*direction.X
+	z := origin.Z + t*direction.Z
+
+	return (x*x + z*z) <= radius*radius+core.EPSILON
+}
+
+func (cyl *Cylinder) GetParent() Shape {
+	return cyl.Parent
+}
+
+func (cyl *Cylinder) SetParent(parent Shape) {
+	cyl.Parent = parent
+}
+
+func (cyl *Cylinder) CastsShadow() bool {
+	return castsShadow(cyl.NoCastShadow, cyl.Parent)
+}
+
+func (cyl *Cylinder) ReceivesShadow() bool {
+	return receivesShadow(cyl.NoReceiveShadow, cyl.Parent)
+}
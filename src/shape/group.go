@@ -0,0 +1,161 @@
+package shape
+
+import (
+	"sort"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+/*
+Group is a collection of shapes that are transformed as a unit.
+
+The transform of every child is relative to the group, so moving a group
+moves all of its children (and groups can be nested). A group has no surface
+of its own; the material of the children is used when shading.
+
+A ray that misses the bounds of the group skips all of its children. The
+bounds are updated when children are added; call Build after changing the
+transforms of children that were already added, and to put the children in a
+bounding volume hierarchy (worth it for groups with many children, like
+meshes).
+
+Intersecting a group never changes it, so a group may be intersected
+concurrently, as long as nothing adds children or builds it at the same time.
+*/
+type Group struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	Children  []Shape
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+
+	// cached bounds of the children, updated by AddChild and Build
+	bounds *BoundingBox
+	// hierarchy of the children, nil until Build is called
+	bvh *BVH
+}
+
+func NewGroup() *Group {
+	return &Group{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		Children:  []Shape{},
+		bounds:    EmptyBoundingBox(),
+	}
+}
+
+// Add shapes to the group, making the group their parent
+func (g *Group) AddChild(children ...Shape) {
+	added := EmptyBoundingBox()
+	for _, child := range children {
+		child.SetParent(g)
+		g.Children = append(g.Children, child)
+		added.Merge(BoundsOf(child))
+	}
+	g.grow(*added)
+}
+
+// Grow the bounds of the group to include the object space box, and those of
+// every group it is part of since their bounds include this group, and forget
+// their hierarchies. Adding children only ever grows the bounds, so the
+// children that were already there don't need to be looked at again
+func (g *Group) grow(box BoundingBox) {
+	for s := Shape(g); s != nil; s = s.GetParent() {
+		if group, ok := s.(*Group); ok {
+			if group.bounds != nil {
+				group.bounds.Merge(box)
+			}
+			group.bvh = nil
+		}
+		// into the space of the parent
+		box = box.Transform(s.GetTransform())
+	}
+}
+
+// Box containing all the children of the group
+func (g *Group) childrenBounds() *BoundingBox {
+	bounds := EmptyBoundingBox()
+	for _, child := range g.Children {
+		bounds.Merge(BoundsOf(child))
+	}
+	return bounds
+}
+
+// Recompute the bounds of the group and build a bounding volume hierarchy
+// over its children, doing the same for every group nested in it
+func (g *Group) Build() {
+	for _, child := range g.Children {
+		BuildHierarchy(child)
+	}
+
+	g.bvh = NewBVH(g.Children)
+	bounds := g.bvh.Bounds()
+	g.bounds = &bounds
+}
+
+// Intersect the ray with every child, each in its own object space
+func (g *Group) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	xs := []Intersection{}
+
+	if len(g.Children) == 0 || !g.Bounds().Intersects(origin, direction) {
+		return xs
+	}
+
+	if g.bvh != nil {
+		xs = g.bvh.Intersect(origin, direction)
+	} else {
+		for _, child := range g.Children {
+			xs = append(xs, intersectChild(child, origin, direction)...)
+		}
+	}
+
+	sort.Slice(xs, func(i, j int) bool {
+		return xs[i].T < xs[j].T
+	})
+
+	return xs
+}
+
+// Intersections always refer to the children of a group, so the normal of a
+// group itself is never needed
+func (g *Group) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	panic("shape: LocalNormalAt called on a group, normals come from its children")
+}
+
+func (g *Group) GetTransform() core.Matrix {
+	return g.Transform
+}
+
+// Box containing all the children of the group
+func (g *Group) Bounds() BoundingBox {
+	// groups not created with NewGroup have no cached bounds until they are
+	// built, compute them without caching so that intersecting the group
+	// doesn't change it
+	if g.bounds == nil {
+		return *g.childrenBounds()
+	}
+	return *g.bounds
+}
+
+func (g *Group) GetMaterial() *material.Material {
+	return &g.Material
+}
+
+func (g *Group) GetParent() Shape {
+	return g.Parent
+}
+
+func (g *Group) SetParent(parent Shape) {
+	g.Parent = parent
+}
+
+func (g *Group) CastsShadow() bool {
+	return castsShadow(g.NoCastShadow, g.Parent)
+}
+
+func (g *Group) ReceivesShadow() bool {
+	return receivesShadow(g.NoReceiveShadow, g.Parent)
+}
@@ -0,0 +1,30 @@
+package shape
+
+// Intersection records where (at "t" along a ray) a ray hit an object. It
+// lives next to Shape, since composite shapes have to report which of their
+// children was hit.
+type Intersection struct {
+	T      float64
+	Object Shape
+	// Barycentric coordinates of the hit on a triangle's surface. Other
+	// shapes leave them at zero
+	U float64
+	V float64
+}
+
+func NewIntersection(t float64, obj Shape) Intersection {
+	return Intersection{T: t, Object: obj}
+}
+
+func NewIntersectionWithUV(t float64, obj Shape, u float64, v float64) Intersection {
+	return Intersection{T: t, Object: obj, U: u, V: v}
+}
+
+// Wrap the t values at which a ray hit obj into intersections
+func intersectionsOf(obj Shape, ts ...float64) []Intersection {
+	xs := make([]Intersection, len(ts))
+	for i, t := range ts {
+		xs[i] = NewIntersection(t, obj)
+	}
+	return xs
+}
@@ -0,0 +1,76 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+// Plane is an infinite flat surface. In object space it is the xz plane, i.e
+// it extends infinitely far in x and z and passes through the origin.
+type Plane struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewPlane() *Plane {
+	return &Plane{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+	}
+}
+
+func (pl *Plane) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	// A ray parallel to the plane never intersects it. A coplanar ray would
+	// intersect it infinitely many times, but the plane is infinitely thin so
+	// it is invisible for such a ray; treat both as misses
+	if math.Abs(direction.Y) < core.EPSILON {
+		return []Intersection{}
+	}
+
+	// the ray can only hit the plane where its y component becomes zero
+	t := -origin.Y / direction.Y
+	return intersectionsOf(pl, t)
+}
+
+// The plane is flat, so the normal is the same everywhere
+func (pl *Plane) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	return *core.NewVector(0, 1, 0)
+}
+
+func (pl *Plane) GetTransform() core.Matrix {
+	return pl.Transform
+}
+
+// The plane is infinite in x and z, and has no thickness
+func (pl *Plane) Bounds() BoundingBox {
+	return *NewBoundingBox(
+		*core.NewPoint(math.Inf(-1), 0, math.Inf(-1)),
+		*core.NewPoint(math.Inf(1), 0, math.Inf(1)),
+	)
+}
+
+func (pl *Plane) GetMaterial() *material.Material {
+	return &pl.Material
+}
+
+func (pl *Plane) GetParent() Shape {
+	return pl.Parent
+}
+
+func (pl *Plane) SetParent(parent Shape) {
+	pl.Parent = parent
+}
+
+func (pl *Plane) CastsShadow() bool {
+	return castsShadow(pl.NoCastShadow, pl.Parent)
+}
+
+func (pl *Plane) ReceivesShadow() bool {
+	return receivesShadow(pl.NoReceiveShadow, pl.Parent)
+}
@@ -0,0 +1,93 @@
+package shape
+
+import (
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+/*
+Shape is implemented by every primitive that can be placed in a world.
+
+Each shape lives in its own object space. The ray is converted into object
+space (using the inverse of the shape's transform) before LocalIntersect is
+called, and LocalNormalAt receives a point that is already in object space
+along with the intersection that produced it.
+Converting the results back into world space is left to the caller, so a new
+primitive only has to describe itself around the origin.
+
+A shape may belong to a Group (or a CSG), in which case its transform is
+relative to that parent. WorldToObject and NormalToWorld take care of walking
+up the parents.
+*/
+type Shape interface {
+	// intersections of the object space ray (origin, direction) with the shape
+	LocalIntersect(origin core.Point, direction core.Vector) []Intersection
+	// normal of the shape at an object space point. Most shapes only need the
+	// point, the hit is there for shapes that interpolate their normals
+	LocalNormalAt(p core.Point, hit Intersection) core.Vector
+	GetTransform() core.Matrix
+	// object space box that fully contains the shape
+	Bounds() BoundingBox
+	// pointer so that callers can tweak the material of a shape in place
+	GetMaterial() *material.Material
+	// group or CSG the shape belongs to, nil if the shape has no parent
+	GetParent() Shape
+	SetParent(parent Shape)
+	// whether the shape blocks the light reaching other shapes, and whether it
+	// is darkened by the shadows of other shapes. Both are turned off with the
+	// NoCastShadow and NoReceiveShadow fields of the shape, or of any of its
+	// parents
+	CastsShadow() bool
+	ReceivesShadow() bool
+}
+
+// Convert a world space point into the object space of the shape, applying the
+// inverse transforms of all the parents of the shape (outermost first)
+func WorldToObject(s Shape, p core.Point) core.Point {
+	if parent := s.GetParent(); parent != nil {
+		p = WorldToObject(parent, p)
+	}
+
+	transform := s.GetTransform()
+	return *transform.Inverse().Multiply(*p.ToMatrix()).ToPoint()
+}
+
+// Convert an object space normal of the shape into world space, walking up
+// through the parents of the shape
+func NormalToWorld(s Shape, normal core.Vector) core.Vector {
+	// use transpose of inverse matrix to convert vector in object space to
+	// world space
+	// world_normal ← transpose(inverse(shape.transform)) * object_normal
+	transform := s.GetTransform()
+	normal = *transform.Inverse().Transpose().Multiply(*normal.ToMatrix()).ToVector()
+	normal = *normal.Normalize()
+
+	if parent := s.GetParent(); parent != nil {
+		normal = NormalToWorld(parent, normal)
+	}
+
+	return normal
+}
+
+// Convert a ray (origin, direction) into the object space of the shape. Unlike
+// WorldToObject, only the transform of the shape itself is applied
+func toObjectSpace(s Shape, origin core.Point, direction core.Vector) (core.Point, core.Vector) {
+	transform := s.GetTransform()
+	invertTransformM := transform.Inverse()
+
+	objectOrigin := invertTransformM.Multiply(*origin.ToMatrix()).ToPoint()
+	objectDirection := invertTransformM.Multiply(*direction.ToMatrix()).ToVector()
+	return *objectOrigin, *objectDirection
+}
+
+// Whether a shape with the given NoCastShadow flag and parent casts shadows,
+// see Shape.CastsShadow
+func castsShadow(noCastShadow bool, parent Shape) bool {
+	return !noCastShadow && (parent == nil || parent.CastsShadow())
+}
+
+// Whether a shape with the given NoReceiveShadow flag and parent receives
+// shadows, see Shape.ReceivesShadow
+func receivesShadow(noReceiveShadow bool, parent Shape) bool {
+	return !noReceiveShadow && (parent == nil || parent.ReceivesShadow())
+}
@@ -1,6 +1,8 @@
 package shape
 
 import (
+	"math"
+
 	core "github.com/Naveenaidu/gray/src/core/math"
 	"github.com/Naveenaidu/gray/src/material"
 )
@@ -10,6 +12,10 @@ type Sphere struct {
 	Radius    float64
 	Transform core.Matrix
 	Material  material.Material
+	Parent    Shape
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
 }
 
 // Sphere with radius 1 and centered at origin (0,0,0)
@@ -21,3 +27,57 @@ func UnitSphere() *Sphere {
 		Material:  material.DefaultMaterial(),
 	}
 }
+
+func (s *Sphere) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	// vector from sphere center, to the ray origin
+	sphereToRay := origin.Subtract(s.Center)
+
+	a := direction.DotProduct(direction)
+	b := 2 * (direction.DotProduct(*sphereToRay))
+	c := sphereToRay.DotProduct(*sphereToRay) - math.Pow(s.Radius, 2)
+
+	discriminant := math.Pow(b, 2) - 4*a*c
+
+	// ray only intersects sphere if the discriminant is greater than zero
+	if discriminant < 0 {
+		return []Intersection{}
+	}
+
+	t1 := (-1*b - math.Sqrt(discriminant)) / (2 * a)
+	t2 := (-1*b + math.Sqrt(discriminant)) / (2 * a)
+	return intersectionsOf(s, t1, t2)
+}
+
+// In object space the normal of a sphere points from its center to the point
+func (s *Sphere) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	return *p.Subtract(s.Center)
+}
+
+func (s *Sphere) GetTransform() core.Matrix {
+	return s.Transform
+}
+
+func (s *Sphere) Bounds() BoundingBox {
+	radius := core.NewVector(s.Radius, s.Radius, s.Radius)
+	return *NewBoundingBox(*s.Center.SubtractVector(*radius), *s.Center.AddVector(*radius))
+}
+
+func (s *Sphere) GetMaterial() *material.Material {
+	return &s.Material
+}
+
+func (s *Sphere) GetParent() Shape {
+	return s.Parent
+}
+
+func (s *Sphere) SetParent(parent Shape) {
+	s.Parent = parent
+}
+
+func (s *Sphere) CastsShadow() bool {
+	return castsShadow(s.NoCastShadow, s.Parent)
+}
+
+func (s *Sphere) ReceivesShadow() bool {
+	return receivesShadow(s.NoReceiveShadow, s.Parent)
+}
@@ -0,0 +1,213 @@
+package shape
+
+import (
+	"math"
+
+	core "github.com/Naveenaidu/gray/src/core/math"
+	"github.com/Naveenaidu/gray/src/material"
+)
+
+/*
+Triangle described by its three corners P1, P2 and P3.
+
+The edge vectors and the normal never change for a given triangle, so they are
+computed once in NewTriangle instead of on every intersection. If you change
+the corners of an existing triangle, create a new one instead.
+*/
+type Triangle struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	P1        core.Point
+	P2        core.Point
+	P3        core.Point
+	E1        core.Vector // P2 - P1
+	E2        core.Vector // P3 - P1
+	Normal    core.Vector
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewTriangle(p1 core.Point, p2 core.Point, p3 core.Point) *Triangle {
+	e1 := p2.Subtract(p1)
+	e2 := p3.Subtract(p1)
+
+	return &Triangle{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		P1:        p1,
+		P2:        p2,
+		P3:        p3,
+		E1:        *e1,
+		E2:        *e2,
+		Normal:    *e2.CrossProduct(*e1).Normalize(),
+	}
+}
+
+func (tri *Triangle) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	t, u, v, hit := mollerTrumbore(tri.P1, tri.E1, tri.E2, origin, direction)
+	if !hit {
+		return []Intersection{}
+	}
+	return []Intersection{NewIntersectionWithUV(t, tri, u, v)}
+}
+
+// A triangle is flat, so it has the same normal everywhere
+func (tri *Triangle) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	return tri.Normal
+}
+
+func (tri *Triangle) GetTransform() core.Matrix {
+	return tri.Transform
+}
+
+func (tri *Triangle) Bounds() BoundingBox {
+	return triangleBounds(tri.P1, tri.P2, tri.P3)
+}
+
+func (tri *Triangle) GetMaterial() *material.Material {
+	return &tri.Material
+}
+
+func (tri *Triangle) GetParent() Shape {
+	return tri.Parent
+}
+
+func (tri *Triangle) SetParent(parent Shape) {
+	tri.Parent = parent
+}
+
+func (tri *Triangle) CastsShadow() bool {
+	return castsShadow(tri.NoCastShadow, tri.Parent)
+}
+
+func (tri *Triangle) ReceivesShadow() bool {
+	return receivesShadow(tri.NoReceiveShadow, tri.Parent)
+}
+
+/*
+SmoothTriangle is a triangle with a normal for each of its corners (N1, N2
+and N3). The normal at a hit is interpolated from the corner normals using
+the u/v of the intersection, which makes a mesh of flat triangles look smooth.
+*/
+type SmoothTriangle struct {
+	Transform core.Matrix
+	Material  material.Material
+	Parent    Shape
+	P1        core.Point
+	P2        core.Point
+	P3        core.Point
+	N1        core.Vector
+	N2        core.Vector
+	N3        core.Vector
+	E1        core.Vector // P2 - P1
+	E2        core.Vector // P3 - P1
+
+	NoCastShadow    bool
+	NoReceiveShadow bool
+}
+
+func NewSmoothTriangle(p1 core.Point, p2 core.Point, p3 core.Point, n1 core.Vector, n2 core.Vector, n3 core.Vector) *SmoothTriangle {
+	return &SmoothTriangle{
+		Transform: *core.IdentityMatrix(),
+		Material:  material.DefaultMaterial(),
+		P1:        p1,
+		P2:        p2,
+		P3:        p3,
+		N1:        n1,
+		N2:        n2,
+		N3:        n3,
+		E1:        *p2.Subtract(p1),
+		E2:        *p3.Subtract(p1),
+	}
+}
+
+func (tri *SmoothTriangle) LocalIntersect(origin core.Point, direction core.Vector) []Intersection {
+	t, u, v, hit := mollerTrumbore(tri.P1, tri.E1, tri.E2, origin, direction)
+	if !hit {
+		return []Intersection{}
+	}
+	return []Intersection{NewIntersectionWithUV(t, tri, u, v)}
+}
+
+// normal = n2 * u + n3 * v + n1 * (1 - u - v)
+func (tri *SmoothTriangle) LocalNormalAt(p core.Point, hit Intersection) core.Vector {
+	return *core.AddVectors([]core.Vector{
+		*tri.N2.ScalarMultiply(hit.U),
+		*tri.N3.ScalarMultiply(hit.V),
+		*tri.N1.ScalarMultiply(1 - hit.U - hit.V),
+	})
+}
+
+func (tri *SmoothTriangle) GetTransform() core.Matrix {
+	return tri.Transform
+}
+
+func (tri *SmoothTriangle) Bounds() BoundingBox {
+	return triangleBounds(tri.P1, tri.P2, tri.P3)
+}
+
+func (tri *SmoothTriangle) GetMaterial() *material.Material {
+	return &tri.Material
+}
+
+func (tri *SmoothTriangle) GetParent() Shape {
+	return tri.Parent
+}
+
+func (tri *SmoothTriangle) SetParent(parent Shape) {
+	tri.Parent = parent
+}
+
+func (tri *SmoothTriangle) CastsShadow() bool {
+	return castsShadow(tri.NoCastShadow, tri.Parent)
+}
+
+func (tri *SmoothTriangle) ReceivesShadow() bool {
+	return receivesShadow(tri.NoReceiveShadow, tri.Parent)
+}
+
+func triangleBounds(p1 core.Point, p2 core.Point, p3 core.Point) BoundingBox {
+	bounds := EmptyBoundingBox()
+	bounds.AddPoint(p1)
+	bounds.AddPoint(p2)
+	bounds.AddPoint(p3)
+	return *bounds
+}
+
+/*
+Möller–Trumbore ray/triangle intersection.
+
+Returns the t of the hit along with u and v, the barycentric coordinates of the
+hit relative to the corners p2 and p3. hit is false if the ray misses the
+triangle or runs parallel to it.
+*/
+func mollerTrumbore(p1 core.Point, e1 core.Vector, e2 core.Vector, origin core.Point, direction core.Vector) (t float64, u float64, v float64, hit bool) {
+	dirCrossE2 := direction.CrossProduct(e2)
+	det := e1.DotProduct(*dirCrossE2)
+
+	// ray is parallel to the triangle
+	if math.Abs(det) < core.EPSILON {
+		return 0, 0, 0, false
+	}
+
+	f := 1.0 / det
+
+	p1ToOrigin := origin.Subtract(p1)
+	u = f * p1ToOrigin.DotProduct(*dirCrossE2)
+	// ray misses the p1-p3 edge, or passes beyond the p2-p3 edge
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	originCrossE1 := p1ToOrigin.CrossProduct(e1)
+	v = f * direction.DotProduct(*originCrossE1)
+	// ray misses the p1-p2 edge, or passes beyond the p2-p3 edge
+	if v < 0 || (u+v) > 1 {
+		return 0, 0, 0, false
+	}
+
+	t = f * e2.DotProduct(*originCrossE1)
+	return t, u, v, true
+}